@@ -0,0 +1,52 @@
+package conditional
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Exister can be implemented by resources that may not exist and have no
+// Etagger to report ErrNoResource through.
+type Exister interface {
+	Exists() bool
+}
+
+// Reports whether resource has no current representation, r holds its
+// validators. Binding evaluates without a resource, from r alone.
+func resourceAbsent(resource interface{}, r resolved) bool {
+	if e, ok := resource.(Exister); ok {
+		return !e.Exists()
+	}
+	if r.etag != nil {
+		_, err := r.etag.Etag()
+		return errors.Is(err, ErrNoResource)
+	}
+	return resource == nil && r.modified == nil
+}
+
+// Evaluates preconditions for a resource that does not exist. Every
+// precondition is decided the same way regardless of which validators the
+// resource implements:
+//
+//   - If-Match or If-Unmodified-Since fail with ErrWasModified (412), the
+//     client expected to change an existing state.
+//   - If-None-Match always passes, so "If-None-Match: *" creates.
+//   - If-Modified-Since and If-Range are ignored.
+//
+// GET and HEAD then return ErrNoResource for the caller to answer 404,
+// other methods proceed. Requests without preconditions are not probed for
+// absence, Serve answers them with 404 when the content cannot be opened.
+func (cfg *Config) absent(c *gin.Context, e *Evaluation) (bool, error) {
+	for _, name := range []string{IfMatch, IfUnmodifiedSince} {
+		if cfg.header(c.Request, name) != "" {
+			e.Header = name
+			return false, ErrWasModified
+		}
+	}
+
+	if c.Request.Method == Get || c.Request.Method == Head {
+		return false, ErrNoResource
+	}
+	return false, nil
+}
@@ -0,0 +1,36 @@
+package conditional
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// gin.Context keys set by Conditional for access logging middlewares.
+const (
+	// Whether the request was answered with 304, a bool.
+	HitKey = "conditional.hit"
+
+	// The outcome and deciding header, such as
+	// "not-modified/if-none-match", a string.
+	ReasonKey = "conditional.reason"
+
+	// Body bytes a 304 or 206 did not send, as far as known, an int64.
+	// See Sizer.
+	BytesSavedKey = "conditional.bytes_saved"
+)
+
+func setLogKeys(c *gin.Context, e Evaluation) {
+	c.Set(HitKey, e.Status == http.StatusNotModified)
+
+	reason := e.Outcome()
+	if e.Header != "" {
+		reason += "/" + strings.ToLower(e.Header)
+	}
+	c.Set(ReasonKey, reason)
+
+	if _, ok := c.Get(BytesSavedKey); !ok {
+		c.Set(BytesSavedKey, int64(0))
+	}
+}
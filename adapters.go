@@ -0,0 +1,93 @@
+package conditional
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+)
+
+// TextEtag returns an Etagger deriving a strong ETag from v's MarshalText
+// output with DefaultConfig's hash, for simple value types like enums or
+// settings structs.
+func TextEtag(v encoding.TextMarshaler) Etagger {
+	return DefaultConfig.TextEtag(v)
+}
+
+func (cfg *Config) TextEtag(v encoding.TextMarshaler) Etagger {
+	return textEtagger{cfg, v}
+}
+
+// StringEtag returns an Etagger deriving a strong ETag from v's String
+// output with DefaultConfig's hash. Only use it when String describes the
+// whole value.
+func StringEtag(v fmt.Stringer) Etagger {
+	return DefaultConfig.StringEtag(v)
+}
+
+func (cfg *Config) StringEtag(v fmt.Stringer) Etagger {
+	return stringEtagger{cfg, v}
+}
+
+type textEtagger struct {
+	cfg   *Config
+	value encoding.TextMarshaler
+}
+
+func (t textEtagger) Etag() (string, error) {
+	text, err := t.value.MarshalText()
+	if err != nil {
+		return "", err
+	}
+	return t.cfg.EtagFromBytes(text), nil
+}
+
+type stringEtagger struct {
+	cfg   *Config
+	value fmt.Stringer
+}
+
+func (s stringEtagger) Etag() (string, error) {
+	return s.cfg.EtagFromBytes([]byte(s.value.String())), nil
+}
+
+// EtagFromJSON returns a strong ETag for v's canonical JSON encoding:
+// object keys sorted, no insignificant whitespace and no HTML escaping,
+// so the same value always yields the same ETag regardless of map
+// iteration order or custom marshaler formatting.
+func EtagFromJSON(v interface{}) (string, error) {
+	return DefaultConfig.EtagFromJSON(v)
+}
+
+func (cfg *Config) EtagFromJSON(v interface{}) (string, error) {
+	canonical, err := canonicalJSON(v)
+	if err != nil {
+		return "", err
+	}
+	return cfg.EtagFromBytes(canonical), nil
+}
+
+// Encodes v, then decodes and re-encodes it generically, which sorts the
+// keys of every object and compacts the output. Numbers are kept as
+// written.
+func canonicalJSON(v interface{}) ([]byte, error) {
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(encoded))
+	dec.UseNumber()
+	var generic interface{}
+	if err := dec.Decode(&generic); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(generic); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
@@ -0,0 +1,86 @@
+package conditional
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+)
+
+type version int
+
+func (v version) MarshalText() ([]byte, error) {
+	if v < 0 {
+		return nil, errors.New("negative version")
+	}
+	return []byte(strconv.Itoa(int(v))), nil
+}
+
+func (v version) String() string {
+	return "v" + strconv.Itoa(int(v))
+}
+
+func TestValueEtaggers(t *testing.T) {
+	cfg := &Config{EtagNamespace: "build-2"}
+	tests := []struct {
+		name    string
+		etagger Etagger
+		want    string
+		err     bool
+	}{
+		{"text", TextEtag(version(3)), EtagFromBytes([]byte("3")), false},
+		{"text config", cfg.TextEtag(version(3)), cfg.EtagFromBytes([]byte("3")), false},
+		{"text error", TextEtag(version(-1)), "", true},
+		{"string", StringEtag(version(3)), EtagFromBytes([]byte("v3")), false},
+		{"string config", cfg.StringEtag(version(3)), cfg.EtagFromBytes([]byte("v3")), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			etag, err := tt.etagger.Etag()
+			if (err != nil) != tt.err || etag != tt.want {
+				t.Errorf("Etag() = %q, %v, want %q", etag, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestEtagFromJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b interface{}
+		same bool
+	}{
+		{"key order", map[string]int{"a": 1, "b": 2}, map[string]int{"b": 2, "a": 1}, true},
+		{"struct and map", struct {
+			B int `json:"b"`
+			A int `json:"a"`
+		}{2, 1}, map[string]int{"a": 1, "b": 2}, true},
+		{"html kept", map[string]string{"a": "<b>"}, map[string]string{"a": "<b>"}, true},
+		{"value change", map[string]int{"a": 1}, map[string]int{"a": 2}, false},
+		{"number kept as written", []float64{1.0}, []int{1}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, err := EtagFromJSON(tt.a)
+			if err != nil {
+				t.Fatal(err)
+			}
+			b, err := EtagFromJSON(tt.b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (a == b) != tt.same {
+				t.Errorf("%s and %s: equal = %v, want %v", a, b, a == b, tt.same)
+			}
+		})
+	}
+
+	if _, err := EtagFromJSON(func() {}); err == nil {
+		t.Error("unencodable value accepted")
+	}
+	cfg := &Config{EtagNamespace: "build-2"}
+	a, _ := EtagFromJSON(1)
+	b, _ := cfg.EtagFromJSON(1)
+	if a == b {
+		t.Error("Config.EtagFromJSON ignores the namespace")
+	}
+}
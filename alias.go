@@ -0,0 +1,66 @@
+package conditional
+
+import "net/http"
+
+// HeaderAlias maps a custom request header onto the semantics of a
+// standard precondition header, for clients that cannot set the standard
+// one. For example X-If-Version evaluated as If-Match, with the ETag
+// mirrored back in X-Resource-Version.
+type HeaderAlias struct {
+	// Custom request header, e.g. "X-If-Version".
+	Request string
+
+	// Standard header it stands in for, e.g. IfMatch.
+	Standard string
+
+	// Optional response header mirroring the validator the standard
+	// header is compared against, ETag or Last-Modified.
+	Response string
+}
+
+// Returns the value of a single valued conditional header, falling back
+// to the first configured alias present on the request.
+func (cfg *Config) header(r *http.Request, name string) string {
+	if values := cfg.values(r, name); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// Returns every line of a conditional header, clients and proxies may
+// send a list as repeated lines rather than one comma-joined value. Lines
+// of configured aliases are only used when the standard header is absent.
+func (cfg *Config) values(r *http.Request, name string) []string {
+	if values := r.Header.Values(name); len(values) > 0 {
+		return values
+	}
+
+	for _, alias := range cfg.Aliases {
+		if alias.Standard != name {
+			continue
+		}
+		if values := r.Header.Values(alias.Request); len(values) > 0 {
+			return values
+		}
+	}
+	return nil
+}
+
+// Copies validators already set on header into the aliases' mirrored
+// response headers.
+func (cfg *Config) mirror(header http.Header) {
+	for _, alias := range cfg.Aliases {
+		if alias.Response == "" {
+			continue
+		}
+
+		source := ETag
+		if alias.Standard == IfModifiedSince || alias.Standard == IfUnmodifiedSince {
+			source = LastModified
+		}
+
+		if value := header.Get(source); value != "" {
+			header.Set(alias.Response, value)
+		}
+	}
+}
@@ -0,0 +1,249 @@
+package conditional
+
+import (
+	"errors"
+	"hash"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Memory buffered per response by AutoEtag when Config.BufferLimit is
+// zero.
+const DefaultBufferLimit = 1 << 20
+
+// AutoEtag buffers the handler's response, computes a strong ETag from the
+// body and answers 304 when it matches If-None-Match. It gives endpoints
+// without a natural validator conditional GET support, and Range support
+// through the buffered body.
+//
+// Responses other than a 200 to a GET, responses that already carry an
+// ETag, and handlers that flush or write no body are passed through
+// untouched. HEAD requests have no body to hash, so they are too. Bodies over
+// the BufferLimit are passed through too, unless SpillToDisk is set. With
+// StreamingHash the body is hashed as it is written rather than once the
+// handler returns.
+//
+// Bodies larger than AutoEtagMaxSize, and content types not listed in
+// AutoEtagTypes, are passed through. Without AutoEtagTypes, event streams,
+// application/octet-stream and attachments are skipped.
+//
+// When a Content-Encoding is set on the response, as compressing
+// middlewares do, the ETag is weak, suffixed with the encoding and
+// Accept-Encoding is added to Vary. It is also weak when
+// Config.TransformedBody is set.
+func AutoEtag() gin.HandlerFunc {
+	return DefaultConfig.AutoEtag()
+}
+
+func (cfg *Config) AutoEtag() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method != Get {
+			c.Next()
+			return
+		}
+
+		limit := cfg.BufferLimit
+		if limit == 0 {
+			limit = DefaultBufferLimit
+		}
+		buf := NewSpillBuffer(limit, cfg.SpillToDisk)
+		buf.TempDir = cfg.SpillDir
+		defer buf.Close()
+
+		w := &bufferedWriter{ResponseWriter: c.Writer, cfg: cfg, buf: buf, status: c.Writer.Status()}
+		if cfg.StreamingHash {
+			w.hash = cfg.newHash()
+		}
+		c.Writer = w
+		c.Next()
+		c.Writer = w.ResponseWriter
+
+		if w.passthrough {
+			return
+		}
+
+		header := c.Writer.Header()
+		if w.status != http.StatusOK || header.Get(ETag) != "" || !w.checked {
+			w.release()
+			return
+		}
+
+		var err error
+		var etag string
+		if w.hash != nil {
+			etag = cfg.etagFromSum(w.hash.Sum(nil))
+		} else if etag, err = cfg.hashEtagReader(buf.ReadSeeker()); err != nil {
+			w.release()
+			return
+		}
+		if encodingActive(header) {
+			// Identity and encoded representations must never share a
+			// validator.
+			etag = EncodedEtag(etag, header.Get("Content-Encoding"))
+			addVary(header, "Accept-Encoding")
+		}
+		if cfg.TransformedBody || encodingActive(header) {
+			// The bytes sent will differ from the ones hashed, so the tag
+			// only promises semantic equivalence.
+			etag = "W/" + etag
+		}
+		header.Set(ETag, etag)
+
+		handled, err := cfg.Conditional(c, etagValue(etag))
+		if handled {
+			return
+		}
+		if status := errorStatus(err); status != 0 {
+			c.AbortWithStatus(status)
+			return
+		}
+
+		cfg.ServeRange(c, buf.ReadSeeker(), buf.Len())
+	}
+}
+
+// Content types AutoEtag never buffers unless listed in AutoEtagTypes:
+// event streams and opaque binary downloads.
+var autoEtagSkipped = []string{"text/event-stream", "application/octet-stream"}
+
+// Decides, once the handler starts writing, whether a body is worth
+// hashing given its headers.
+func (cfg *Config) autoEtagEligible(header http.Header) bool {
+	if cfg.AutoEtagMaxSize > 0 {
+		if n, err := strconv.ParseInt(header.Get("Content-Length"), 10, 64); err == nil && n > cfg.AutoEtagMaxSize {
+			return false
+		}
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(header.Get("Content-Type"))
+	if len(cfg.AutoEtagTypes) > 0 {
+		return mediaTypeMatches(cfg.AutoEtagTypes, mediaType)
+	}
+
+	if strings.HasPrefix(strings.ToLower(header.Get("Content-Disposition")), "attachment") {
+		return false
+	}
+	return !mediaTypeMatches(autoEtagSkipped, mediaType)
+}
+
+// Matches a media type against patterns like "application/json" or "text/*".
+func mediaTypeMatches(patterns []string, mediaType string) bool {
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(pattern)
+		if pattern == mediaType ||
+			strings.HasSuffix(pattern, "/*") && strings.HasPrefix(mediaType, pattern[:len(pattern)-1]) {
+			return true
+		}
+	}
+	return false
+}
+
+// bufferedWriter holds the handler's status and body until the handler
+// returns. It turns into a plain pass-through writer when the body
+// outgrows the buffer or the handler flushes.
+type bufferedWriter struct {
+	gin.ResponseWriter
+	cfg         *Config
+	checked     bool
+	buf         *SpillBuffer
+	hash        hash.Hash
+	status      int
+	wroteHeader bool
+	passthrough bool
+}
+
+func (w *bufferedWriter) WriteHeader(code int) {
+	if w.passthrough {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	if code > 0 && !w.wroteHeader {
+		w.status = code
+	}
+}
+
+func (w *bufferedWriter) WriteHeaderNow() {
+	if w.passthrough {
+		w.ResponseWriter.WriteHeaderNow()
+		return
+	}
+	w.wroteHeader = true
+}
+
+func (w *bufferedWriter) Write(p []byte) (int, error) {
+	if w.passthrough {
+		return w.ResponseWriter.Write(p)
+	}
+
+	if !w.checked {
+		w.checked = true
+		if !w.cfg.autoEtagEligible(w.Header()) {
+			w.release()
+			return w.ResponseWriter.Write(p)
+		}
+	}
+	if w.cfg.AutoEtagMaxSize > 0 && w.buf.Len()+int64(len(p)) > w.cfg.AutoEtagMaxSize {
+		w.release()
+		return w.ResponseWriter.Write(p)
+	}
+
+	w.wroteHeader = true
+	n, err := w.buf.Write(p)
+	if errors.Is(err, ErrBufferFull) {
+		w.release()
+		return w.ResponseWriter.Write(p)
+	}
+	if w.hash != nil {
+		w.hash.Write(p[:n])
+	}
+	return n, err
+}
+
+func (w *bufferedWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *bufferedWriter) Status() int {
+	if w.passthrough {
+		return w.ResponseWriter.Status()
+	}
+	return w.status
+}
+
+func (w *bufferedWriter) Size() int {
+	if w.passthrough {
+		return w.ResponseWriter.Size()
+	}
+	if !w.wroteHeader {
+		return -1
+	}
+	return int(w.buf.Len())
+}
+
+func (w *bufferedWriter) Written() bool {
+	if w.passthrough {
+		return w.ResponseWriter.Written()
+	}
+	return w.wroteHeader
+}
+
+// A flushing handler is streaming, so buffering stops.
+func (w *bufferedWriter) Flush() {
+	w.release()
+	w.ResponseWriter.Flush()
+}
+
+// Writes out what was buffered and passes everything else through.
+func (w *bufferedWriter) release() {
+	if w.passthrough {
+		return
+	}
+	w.passthrough = true
+	w.ResponseWriter.WriteHeader(w.status)
+	w.buf.WriteTo(w.ResponseWriter)
+	w.buf.Close()
+}
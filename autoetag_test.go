@@ -0,0 +1,101 @@
+package conditional
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func autoEtagRouter(cfg *Config, body string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/", cfg.AutoEtag(), func(c *gin.Context) {
+		c.Header("Content-Type", "text/plain")
+		// Written in pieces, so hashing is incremental.
+		for i := 0; i < len(body); i += 3 {
+			c.Writer.WriteString(body[i:min(i+3, len(body))])
+		}
+	})
+	return r
+}
+
+func TestStreamingHashUnderCutoff(t *testing.T) {
+	cfg := &Config{StreamingHash: true, BufferLimit: 16}
+	body := "fits the buffer"
+	r := autoEtagRouter(cfg, body)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(Get, "/", nil))
+	want, _ := cfg.hashEtagReader(strings.NewReader(body))
+	if w.Code != http.StatusOK || w.Body.String() != body || w.Header().Get(ETag) != want {
+		t.Fatalf("got %d %q with ETag %q, want %q", w.Code, w.Body.String(), w.Header().Get(ETag), want)
+	}
+
+	w = httptest.NewRecorder()
+	req := httptest.NewRequest(Get, "/", nil)
+	req.Header.Set(IfNoneMatch, want)
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+		t.Errorf("revalidation got %d %q, want an empty 304", w.Code, w.Body.String())
+	}
+}
+
+func TestStreamingHashOverCutoff(t *testing.T) {
+	cfg := &Config{StreamingHash: true, BufferLimit: 16}
+	body := strings.Repeat("outgrows it ", 4)
+	r := autoEtagRouter(cfg, body)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(Get, "/", nil)
+	req.Header.Set(IfNoneMatch, `"anything"`)
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || w.Body.String() != body {
+		t.Errorf("got %d %q, want the full body", w.Code, w.Body.String())
+	}
+	if etag := w.Header().Get(ETag); etag != "" {
+		t.Errorf("ETag %q on a passed through body", etag)
+	}
+}
+
+func TestAutoEtagBuffered(t *testing.T) {
+	cfg := &Config{}
+	r := autoEtagRouter(cfg, "body")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(Get, "/", nil))
+	streamed := &Config{StreamingHash: true}
+	want, _ := streamed.hashEtagReader(strings.NewReader("body"))
+	if etag := w.Header().Get(ETag); etag != want {
+		t.Fatalf("ETag %q, want %q as in streaming mode", etag, want)
+	}
+
+	w = httptest.NewRecorder()
+	req := httptest.NewRequest(Get, "/", nil)
+	req.Header.Set(IfNoneMatch, want)
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusNotModified {
+		t.Errorf("got %d, want 304", w.Code)
+	}
+}
+
+func TestAutoEtagWithoutBody(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	handler := func(c *gin.Context) {
+		c.Header("Content-Length", "1234")
+		c.Status(http.StatusOK)
+	}
+	r.GET("/", AutoEtag(), handler)
+	r.HEAD("/", AutoEtag(), handler)
+
+	for _, method := range []string{Head, Get} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(method, "/", nil))
+		if w.Code != http.StatusOK || w.Header().Get(ETag) != "" || w.Header().Get("Content-Length") != "1234" {
+			t.Errorf("%s: got %d with ETag %q and Content-Length %q", method,
+				w.Code, w.Header().Get(ETag), w.Header().Get("Content-Length"))
+		}
+	}
+}
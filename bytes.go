@@ -0,0 +1,45 @@
+package conditional
+
+import (
+	"bytes"
+	"io"
+	"time"
+)
+
+// Bytes is an in-memory resource, such as a rendered report or export,
+// with full conditional and range support.
+type Bytes struct {
+	data         []byte
+	etag         string
+	lastModified time.Time
+}
+
+// BytesResource returns a resource serving data. A strong ETag is computed
+// from data with DefaultConfig's hash when etag is empty, and lastModified
+// may be zero when unknown.
+func BytesResource(data []byte, etag string, lastModified time.Time) *Bytes {
+	return DefaultConfig.BytesResource(data, etag, lastModified)
+}
+
+func (cfg *Config) BytesResource(data []byte, etag string, lastModified time.Time) *Bytes {
+	if etag == "" {
+		etag = cfg.EtagFromBytes(data)
+	}
+	return &Bytes{data: data, etag: etag, lastModified: lastModified}
+}
+
+func (b *Bytes) Etag() (string, error) {
+	return b.etag, nil
+}
+
+func (b *Bytes) LastModified() time.Time {
+	return b.lastModified
+}
+
+func (b *Bytes) Size() int64 {
+	return int64(len(b.data))
+}
+
+func (b *Bytes) Content() (io.ReadSeeker, int64, error) {
+	return bytes.NewReader(b.data), int64(len(b.data)), nil
+}
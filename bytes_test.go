@@ -0,0 +1,38 @@
+package conditional
+
+import (
+	"io"
+	"testing"
+	"time"
+)
+
+func TestBytesResource(t *testing.T) {
+	cfg := &Config{EtagNamespace: "build-2"}
+	tests := []struct {
+		name     string
+		resource *Bytes
+		etag     string
+	}{
+		{"given", BytesResource([]byte("body"), `"v1"`, time.Time{}), `"v1"`},
+		{"hashed", BytesResource([]byte("body"), "", time.Time{}), EtagFromBytes([]byte("body"))},
+		{"config hash", cfg.BytesResource([]byte("body"), "", time.Time{}), cfg.EtagFromBytes([]byte("body"))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if etag, _ := tt.resource.Etag(); etag != tt.etag {
+				t.Errorf("Etag = %q, want %q", etag, tt.etag)
+			}
+			content, size, err := tt.resource.Content()
+			if err != nil || size != 4 {
+				t.Fatalf("Content: %d, %v", size, err)
+			}
+			if b, _ := io.ReadAll(content); string(b) != "body" {
+				t.Errorf("content %q", b)
+			}
+		})
+	}
+
+	if EtagFromBytes([]byte("body")) == cfg.EtagFromBytes([]byte("body")) {
+		t.Error("EtagNamespace not applied")
+	}
+}
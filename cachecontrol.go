@@ -0,0 +1,175 @@
+package conditional
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CacheControl builds a Cache-Control response header. It is a
+// CachePolicy, so one value can be shared by routes:
+//
+//	conditional.CacheControl{MaxAge: 5 * time.Minute, Public: true, MustRevalidate: true}.Apply(c)
+//
+// A zero MaxAge omits max-age; NoCache asks caches to revalidate on every
+// use instead.
+//
+// With Expires, Apply also sets an Expires header for HTTP/1.0 caches,
+// MaxAge after the response's Date, or already past when the response
+// must not be reused without revalidation. Clock replaces time.Now for
+// the Date when the handler sets none.
+// https://tools.ietf.org/html/rfc9111#section-5.2.2
+type CacheControl struct {
+	MaxAge         time.Duration
+	Public         bool
+	Private        bool
+	NoCache        bool
+	NoStore        bool
+	MustRevalidate bool
+	NoTransform    bool
+	Immutable      bool
+
+	// RFC 5861 extensions, letting caches serve a stale response while
+	// they revalidate it, or when revalidation fails. Only emitted with a
+	// MaxAge or SharedMaxAge, which they extend.
+	// https://tools.ietf.org/html/rfc5861
+	StaleWhileRevalidate time.Duration
+	StaleIfError         time.Duration
+
+	// Directives for shared caches only: s-maxage overrides MaxAge for
+	// them, and proxy-revalidate is must-revalidate for them alone.
+	SharedMaxAge    time.Duration
+	ProxyRevalidate bool
+
+	// Policies for CDNs, keyed by targeted header such as
+	// CDNCacheControl, so edge TTLs are set apart from browser ones.
+	// https://tools.ietf.org/html/rfc9213
+	Targeted map[string]CacheControl
+
+	Expires bool
+	Clock   func() time.Time
+}
+
+// Targeted cache control headers understood by CDNs.
+const (
+	CDNCacheControl           = "CDN-Cache-Control"
+	CloudflareCDNCacheControl = "Cloudflare-CDN-Cache-Control"
+	AkamaiCacheControl        = "Akamai-Cache-Control"
+)
+
+// Apply sets the Cache-Control header of c's response, the Targeted ones,
+// and Expires when asked to.
+func (cc CacheControl) Apply(c *gin.Context) {
+	header := c.Writer.Header()
+	if value := cc.String(); value != "" {
+		header.Set("Cache-Control", value)
+	}
+	for name, targeted := range cc.Targeted {
+		if value := targeted.String(); value != "" {
+			header.Set(name, value)
+		}
+	}
+	if cc.Expires {
+		cc.setExpires(header)
+	}
+}
+
+// Sets Expires relative to the response's Date, so both describe the same
+// freshness lifetime as max-age.
+// https://tools.ietf.org/html/rfc9111#section-5.3
+func (cc CacheControl) setExpires(header http.Header) {
+	date, err := http.ParseTime(header.Get("Date"))
+	if err != nil {
+		now := time.Now
+		if cc.Clock != nil {
+			now = cc.Clock
+		}
+		date = now().UTC().Truncate(time.Second)
+		header.Set("Date", date.Format(http.TimeFormat))
+	}
+
+	expires := date
+	if !cc.NoCache && !cc.NoStore {
+		expires = date.Add(cc.MaxAge)
+	}
+	header.Set("Expires", expires.UTC().Format(http.TimeFormat))
+}
+
+// String returns the header value, directives in a fixed order.
+func (cc CacheControl) String() string {
+	var directives []string
+	add := func(set bool, directive string) {
+		if set {
+			directives = append(directives, directive)
+		}
+	}
+
+	add(cc.Public, "public")
+	add(cc.Private, "private")
+	add(cc.NoCache, "no-cache")
+	add(cc.NoStore, "no-store")
+	if cc.MaxAge > 0 {
+		directives = append(directives, "max-age="+seconds(cc.MaxAge))
+	}
+	if cc.SharedMaxAge > 0 {
+		directives = append(directives, "s-maxage="+seconds(cc.SharedMaxAge))
+	}
+	if cc.fresh() && !cc.NoStore {
+		if cc.StaleWhileRevalidate > 0 {
+			directives = append(directives, "stale-while-revalidate="+seconds(cc.StaleWhileRevalidate))
+		}
+		if cc.StaleIfError > 0 {
+			directives = append(directives, "stale-if-error="+seconds(cc.StaleIfError))
+		}
+	}
+	add(cc.MustRevalidate, "must-revalidate")
+	add(cc.ProxyRevalidate, "proxy-revalidate")
+	add(cc.NoTransform, "no-transform")
+	add(cc.Immutable, "immutable")
+	return strings.Join(directives, ", ")
+}
+
+// Validate reports directives that contradict each other or would be
+// dropped from the header.
+func (cc CacheControl) Validate() error {
+	var errs []error
+	if cc.Public && cc.Private {
+		errs = append(errs, errors.New("conditional: Cache-Control cannot be both public and private"))
+	}
+	if cc.MaxAge < 0 || cc.SharedMaxAge < 0 || cc.StaleWhileRevalidate < 0 || cc.StaleIfError < 0 {
+		errs = append(errs, errors.New("conditional: Cache-Control durations must not be negative"))
+	}
+	if (cc.StaleWhileRevalidate > 0 || cc.StaleIfError > 0) && (!cc.fresh() || cc.NoStore) {
+		errs = append(errs, errors.New("conditional: stale-while-revalidate and stale-if-error need a max-age to extend"))
+	}
+	if cc.StaleWhileRevalidate > 0 && cc.MustRevalidate {
+		errs = append(errs, errors.New("conditional: must-revalidate forbids serving stale responses, it contradicts stale-while-revalidate"))
+	}
+	if cc.SharedMaxAge > 0 && cc.Private {
+		errs = append(errs, errors.New("conditional: s-maxage has no effect on private responses"))
+	}
+	for name, targeted := range cc.Targeted {
+		if targeted.Targeted != nil || targeted.Expires {
+			errs = append(errs, fmt.Errorf("conditional: %s only carries directives", name))
+		}
+		if err := targeted.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Reports whether a freshness lifetime is set, for any cache.
+func (cc CacheControl) fresh() bool {
+	return cc.MaxAge > 0 || cc.SharedMaxAge > 0
+}
+
+// Formats d as delta-seconds, rounding down.
+func seconds(d time.Duration) string {
+	return strconv.FormatInt(int64(d/time.Second), 10)
+}
@@ -0,0 +1,45 @@
+package conditional
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Adds this layer's Cache-Status entry for a revalidation: a hit when
+// answered with 304, a forward of a stale copy otherwise. Requests that
+// are not revalidations get none.
+// https://tools.ietf.org/html/rfc9211
+func (cfg *Config) cacheStatus(c *gin.Context, notModified bool) {
+	if cfg.CacheStatus == "" {
+		return
+	}
+
+	byEtag := cfg.header(c.Request, IfNoneMatch) != ""
+	if !byEtag && cfg.header(c.Request, IfModifiedSince) == "" {
+		return
+	}
+
+	var entry string
+	switch {
+	case notModified && byEtag:
+		entry = "hit; detail=ETAG_MATCH"
+	case notModified:
+		entry = "hit; detail=NOT_MODIFIED_SINCE"
+	case byEtag:
+		entry = "fwd=stale; fwd-status=200; detail=ETAG_MISMATCH"
+	default:
+		entry = "fwd=stale; fwd-status=200; detail=MODIFIED_SINCE"
+	}
+	addCacheStatus(c.Writer.Header(), cfg.CacheStatus+"; "+entry)
+}
+
+// Entries are listed from the origin outwards, so this layer, closest to
+// the origin, goes first.
+func addCacheStatus(header http.Header, entry string) {
+	values := append([]string{entry}, header.Values("Cache-Status")...)
+	header.Del("Cache-Status")
+	for _, v := range values {
+		header.Add("Cache-Status", v)
+	}
+}
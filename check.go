@@ -0,0 +1,163 @@
+package conditional
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CheckHeaders returns a middleware inspecting the caching headers of
+// every response just before they are sent, and warning about
+// combinations that contradict each other:
+//
+//   - no-store together with an ETag or Last-Modified
+//   - a public response setting a cookie
+//   - an ETag that breaks the entity-tag grammar
+//   - a Last-Modified that cannot be parsed or lies in the future
+//   - a 304 missing Vary fields the route's 200s carry
+//
+// Warnings are logged through ErrorLog, or added to the WarningHeader of
+// the response when the Config names one. Register it first, so it sees
+// the headers as other middlewares leave them.
+func CheckHeaders() gin.HandlerFunc {
+	return DefaultConfig.CheckHeaders()
+}
+
+func (cfg *Config) CheckHeaders() gin.HandlerFunc {
+	vary := &routeVary{fields: make(map[string][]string)}
+	return func(c *gin.Context) {
+		w := &checkWriter{ResponseWriter: c.Writer, cfg: cfg, c: c, vary: vary}
+		c.Writer = w
+		c.Next()
+		if !w.Written() {
+			w.check()
+		}
+	}
+}
+
+// The Vary fields seen on 200 responses of each route.
+type routeVary struct {
+	mu     sync.Mutex
+	fields map[string][]string
+}
+
+type checkWriter struct {
+	gin.ResponseWriter
+	cfg     *Config
+	c       *gin.Context
+	vary    *routeVary
+	checked bool
+}
+
+func (w *checkWriter) check() {
+	if w.checked {
+		return
+	}
+	w.checked = true
+
+	header := w.Header()
+	warnings := w.vary.check(w.c.FullPath(), w.Status(), header)
+	warnings = append(warnings, checkHeader(header)...)
+
+	for _, warning := range warnings {
+		if w.cfg.WarningHeader != "" {
+			header.Add(w.cfg.WarningHeader, warning)
+		} else {
+			w.cfg.logf("conditional: %s %s: %s", w.c.Request.Method, w.c.Request.URL.Path, warning)
+		}
+	}
+}
+
+// Returns the warnings about header on its own.
+func checkHeader(header http.Header) []string {
+	var warnings []string
+	cacheControl := strings.ToLower(strings.Join(header.Values("Cache-Control"), ","))
+	directives := map[string]bool{}
+	for _, directive := range strings.Split(cacheControl, ",") {
+		name, _, _ := strings.Cut(strings.TrimSpace(directive), "=")
+		directives[name] = true
+	}
+
+	if directives["no-store"] && (header.Get(ETag) != "" || header.Get(LastModified) != "") {
+		warnings = append(warnings, "no-store response carries validators")
+	}
+	if directives["public"] && header.Get("Set-Cookie") != "" {
+		warnings = append(warnings, "public response sets a cookie")
+	}
+	if directives["public"] && directives["private"] {
+		warnings = append(warnings, "response is both public and private")
+	}
+
+	if etag := header.Get(ETag); etag != "" {
+		if tag, rest, ok := scanEtag(etag); !ok || tag != etag || rest != "" {
+			warnings = append(warnings, "malformed ETag "+etag)
+		}
+	}
+	if value := header.Get(LastModified); value != "" {
+		if t, err := http.ParseTime(value); err != nil {
+			warnings = append(warnings, "malformed Last-Modified "+value)
+		} else if t.After(time.Now().Add(time.Minute)) {
+			warnings = append(warnings, "Last-Modified in the future "+value)
+		}
+	}
+	return warnings
+}
+
+// Records the Vary of a route's 200s, and reports the fields its 304s
+// lack, which lets caches serve a variant to clients it was not meant for.
+func (v *routeVary) check(route string, status int, header http.Header) []string {
+	if route == "" {
+		return nil
+	}
+	fields := varyFields(header)
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	switch status {
+	case http.StatusOK:
+		v.fields[route] = fields
+	case http.StatusNotModified:
+		var missing []string
+		for _, field := range v.fields[route] {
+			if !slices.Contains(fields, field) {
+				missing = append(missing, field)
+			}
+		}
+		if len(missing) > 0 {
+			return []string{"304 missing Vary " + strings.Join(missing, ", ")}
+		}
+	}
+	return nil
+}
+
+// Returns the lower-cased fields listed in Vary.
+func varyFields(header http.Header) []string {
+	var fields []string
+	for _, value := range header.Values("Vary") {
+		for _, field := range strings.Split(value, ",") {
+			if field = strings.ToLower(strings.TrimSpace(field)); field != "" {
+				fields = append(fields, field)
+			}
+		}
+	}
+	return fields
+}
+
+func (w *checkWriter) WriteHeaderNow() {
+	w.check()
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+func (w *checkWriter) Write(p []byte) (int, error) {
+	w.check()
+	return w.ResponseWriter.Write(p)
+}
+
+func (w *checkWriter) WriteString(s string) (int, error) {
+	w.check()
+	return w.ResponseWriter.WriteString(s)
+}
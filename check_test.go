@@ -0,0 +1,84 @@
+package conditional
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestCheckHeader(t *testing.T) {
+	future := time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)
+	past := "Sun, 09 Sep 2001 01:46:40 GMT"
+
+	tests := []struct {
+		name   string
+		header http.Header
+		want   []string
+	}{
+		{"coherent", http.Header{"Cache-Control": {"public, max-age=60"}, ETag: {`"a"`}, LastModified: {past}}, nil},
+		{"no-store with etag", http.Header{"Cache-Control": {"No-Store"}, ETag: {`"a"`}}, []string{"no-store response carries validators"}},
+		{"no-store with last-modified", http.Header{"Cache-Control": {"private", "no-store"}, LastModified: {past}}, []string{"no-store response carries validators"}},
+		{"public cookie", http.Header{"Cache-Control": {"public"}, "Set-Cookie": {"session=1"}}, []string{"public response sets a cookie"}},
+		{"private cookie", http.Header{"Cache-Control": {"private"}, "Set-Cookie": {"session=1"}}, nil},
+		{"public and private", http.Header{"Cache-Control": {"public, private"}}, []string{"response is both public and private"}},
+		{"unquoted etag", http.Header{ETag: {"a"}}, []string{"malformed ETag a"}},
+		{"etag list", http.Header{ETag: {`"a", "b"`}}, []string{`malformed ETag "a", "b"`}},
+		{"weak etag", http.Header{ETag: {`W/"a"`}}, nil},
+		{"malformed date", http.Header{LastModified: {"yesterday"}}, []string{"malformed Last-Modified yesterday"}},
+		{"future date", http.Header{LastModified: {future}}, []string{"Last-Modified in the future " + future}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			for key, values := range tt.header {
+				header[http.CanonicalHeaderKey(key)] = values
+			}
+			if got := checkHeader(header); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckHeaders(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := &Config{WarningHeader: "X-Cache-Warning"}
+	r := gin.New()
+	r.Use(cfg.CheckHeaders())
+	r.GET("/page", func(c *gin.Context) {
+		if c.Query("vary") != "" {
+			c.Header("Vary", "Accept-Encoding, Accept")
+		}
+		if handled, _ := cfg.Conditional(c, BytesResource(nil, `"a"`, time.Time{})); !handled {
+			c.String(http.StatusOK, "page")
+		}
+	})
+	r.GET("/no-store", func(c *gin.Context) {
+		c.Header("Cache-Control", "no-store")
+		c.Header(ETag, `"a"`)
+		c.Status(http.StatusNoContent)
+	})
+
+	tests := []struct {
+		name   string
+		path   string
+		header map[string]string
+		want   []string
+	}{
+		{"200 recording vary", "/page?vary=1", nil, nil},
+		{"304 with vary", "/page?vary=1", map[string]string{IfNoneMatch: `"a"`}, nil},
+		{"304 missing vary", "/page", map[string]string{IfNoneMatch: `"a"`}, []string{"304 missing Vary accept-encoding, accept"}},
+		{"bodiless response", "/no-store", nil, []string{"no-store response carries validators"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := request(r, Get, tt.path, tt.header)
+			if got := w.Header().Values("X-Cache-Warning"); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("warnings %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
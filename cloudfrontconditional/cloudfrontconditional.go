@@ -0,0 +1,76 @@
+// Purges gin-conditional resources from Amazon CloudFront.
+package cloudfrontconditional
+
+import (
+	"context"
+	"log"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudfront"
+	"github.com/aws/aws-sdk-go-v2/service/cloudfront/types"
+)
+
+// API is the part of *cloudfront.Client the Purger uses.
+type API interface {
+	CreateInvalidation(ctx context.Context, params *cloudfront.CreateInvalidationInput, optFns ...func(*cloudfront.Options)) (*cloudfront.CreateInvalidationOutput, error)
+}
+
+// Time allowed for one invalidation request.
+const invalidationTimeout = 10 * time.Second
+
+// Purger is a conditional.AsyncInvalidator creating a CloudFront
+// invalidation for the path of every changed resource:
+//
+//	purger := &cloudfrontconditional.Purger{Client: cloudfront.NewFromConfig(awsCfg), DistributionID: "E2EXAMPLE"}
+//	cfg.Invalidators = append(cfg.Invalidators, purger)
+//
+// With the default TargetKey keys are absolute URLs and their path is
+// invalidated; Path maps other keys. Failures are logged to ErrorLog.
+type Purger struct {
+	Client         API
+	DistributionID string
+	Path           func(key string) string
+	ErrorLog       *log.Logger
+}
+
+func (p *Purger) OnResourceChanged(key string) {
+	path := p.path(key)
+	ctx, cancel := context.WithTimeout(context.Background(), invalidationTimeout)
+	defer cancel()
+
+	_, err := p.Client.CreateInvalidation(ctx, &cloudfront.CreateInvalidationInput{
+		DistributionId: aws.String(p.DistributionID),
+		InvalidationBatch: &types.InvalidationBatch{
+			// Identifies the request, so retries of it are not
+			// invalidated twice.
+			CallerReference: aws.String(path + "@" + strconv.FormatInt(time.Now().UnixNano(), 10)),
+			Paths:           &types.Paths{Quantity: aws.Int32(1), Items: []string{path}},
+		},
+	})
+	if err != nil {
+		if p.ErrorLog != nil {
+			p.ErrorLog.Printf("conditional: invalidating %s: %v", path, err)
+		} else {
+			log.Printf("conditional: invalidating %s: %v", path, err)
+		}
+	}
+}
+
+// Async implements conditional.AsyncInvalidator, invalidations run in
+// the background.
+func (p *Purger) Async() bool {
+	return true
+}
+
+func (p *Purger) path(key string) string {
+	if p.Path != nil {
+		return p.Path(key)
+	}
+	if u, err := url.Parse(key); err == nil && u.Path != "" {
+		return u.EscapedPath()
+	}
+	return key
+}
@@ -0,0 +1,37 @@
+package cloudfrontconditional
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/cloudfront"
+)
+
+type fakeAPI struct {
+	inputs []*cloudfront.CreateInvalidationInput
+}
+
+func (f *fakeAPI) CreateInvalidation(ctx context.Context, params *cloudfront.CreateInvalidationInput, optFns ...func(*cloudfront.Options)) (*cloudfront.CreateInvalidationOutput, error) {
+	f.inputs = append(f.inputs, params)
+	return &cloudfront.CreateInvalidationOutput{}, nil
+}
+
+func TestPurgerInvalidatesPath(t *testing.T) {
+	api := &fakeAPI{}
+	p := &Purger{Client: api, DistributionID: "E2EXAMPLE"}
+	p.OnResourceChanged("https://example.com/articles/1?draft=1")
+	p.OnResourceChanged("/articles/2")
+
+	if len(api.inputs) != 2 {
+		t.Fatalf("%d invalidations, want 2", len(api.inputs))
+	}
+	for i, want := range []string{"/articles/1", "/articles/2"} {
+		in := api.inputs[i]
+		if *in.DistributionId != "E2EXAMPLE" || in.InvalidationBatch.Paths.Items[0] != want || *in.InvalidationBatch.Paths.Quantity != 1 {
+			t.Errorf("invalidation %d: %s %v, want %s", i, *in.DistributionId, in.InvalidationBatch.Paths.Items, want)
+		}
+	}
+	if *api.inputs[0].InvalidationBatch.CallerReference == *api.inputs[1].InvalidationBatch.CallerReference {
+		t.Error("invalidations share a CallerReference")
+	}
+}
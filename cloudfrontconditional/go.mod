@@ -0,0 +1,16 @@
+module github.com/itsjamie/gin-conditional/cloudfrontconditional
+
+go 1.25.0
+
+require (
+	github.com/aws/aws-sdk-go-v2 v1.47.0
+	github.com/aws/aws-sdk-go-v2/service/cloudfront v1.73.0
+)
+
+require (
+	github.com/aws/aws-sdk-go-v2/internal/configsources v1.5.3 // indirect
+	github.com/aws/aws-sdk-go-v2/internal/endpoints/v2 v2.8.3 // indirect
+	github.com/aws/smithy-go v1.28.1 // indirect
+)
+
+replace github.com/itsjamie/gin-conditional => ../
@@ -4,6 +4,7 @@ package conditional
 import (
 	"errors"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -18,14 +19,26 @@ const (
 	IfRange           = "If-Range"
 )
 
+// HTTP headers used to advertise validators
+const (
+	ETag         = "ETag"
+	LastModified = "Last-Modified"
+)
+
 // HTTP Methods that are checked for when calculating conditional requests
 const (
-	Get  = "GET"
-	Head = "HEAD"
+	Get    = "GET"
+	Head   = "HEAD"
+	Put    = "PUT"
+	Patch  = "PATCH"
+	Delete = "DELETE"
 )
 
-// HTTP header used when calculating Range based conditional requests
-const Range = "Range"
+// HTTP headers used when calculating Range based conditional requests
+const (
+	Range        = "Range"
+	AcceptRanges = "Accept-Ranges"
+)
 
 type Etagger interface {
 	// Etag
@@ -36,6 +49,8 @@ type LastModifier interface {
 	LastModified() time.Time
 }
 
+// Conditional returns these wrapped in an Error, test for them with
+// errors.Is.
 var (
 	// An error that the Etag function can return to signify that
 	// no resource exists at the given Location.
@@ -49,136 +64,356 @@ var (
 	//    in the current state of the target resource
 	ErrWasModified = errors.New("Resource was modified since header, check if final state would match")
 
+	// If-Range did not match. Conditional has already removed the Range
+	// header and set the current validators, the caller should proceed
+	// and send the entire resource with a 200.
 	ErrRangeMismatch = errors.New("Calculating If-Range failed, respond with entire resource")
+
+	// A conditional header could not be parsed and the Config asks for
+	// malformed headers to fail the request, usually with a 400.
+	ErrMalformedHeader = errors.New("Malformed conditional header")
+
+	// The request carries a precondition the resource has no validator
+	// for, and the Config asks for this to be reported.
+	ErrUnsupportedPrecondition = errors.New("Resource cannot evaluate the requested precondition")
+
+	// The resource's Etag failed with an error other than ErrNoResource,
+	// such as a database timeout, so no precondition could be decided.
+	// The resource's error is wrapped along, usually answered with a 500.
+	ErrValidatorFailed = errors.New("Computing the resource's validators failed")
 )
 
+// Conditional evaluates the request's preconditions against resource
+// using the request's Config, see ConfigOf. When a GET or HEAD proceeds,
+// the resource's ETag and Last-Modified are set on the response, unless
+// the handler set them already or the Config has OmitValidators, so
+// clients learn the validators to revalidate with. An error computing an
+// ETag a precondition needs, other than ErrNoResource, is returned without
+// answering the request, as ErrValidatorFailed.
 func Conditional(c *gin.Context, resource interface{}) (bool, error) {
-	etagger, canCheckEtag := resource.(Etagger)
-	modifier, canCheckModifier := resource.(LastModifier)
+	return ConfigOf(c).Conditional(c, resource)
+}
+
+func (cfg *Config) Conditional(c *gin.Context, resource interface{}) (bool, error) {
+	return cfg.conditional(c, resource, cfg.resolve(c, resource))
+}
+
+// Evaluates the request against resource and its validators r, then sets
+// the response headers for the outcome. Binding passes a nil resource.
+func (cfg *Config) conditional(c *gin.Context, resource interface{}, r resolved) (bool, error) {
+	varyVariant(c, resource)
+	setSurrogate(c.Writer.Header(), resource)
+	var e Evaluation
+	handled, err := cfg.evaluate(c, resource, r, &e)
+	err = wrapError(err, e.Header)
+	if e.Status == http.StatusNotModified {
+		cfg.notModifiedHeader(c, resource, r)
+	}
+	if errors.Is(err, ErrRangeMismatch) {
+		cfg.rangeFallback(c, resource, r)
+	}
+	if !handled && errorStatus(err) == 0 {
+		cfg.advertiseRanges(c.Writer.Header(), resource)
+		if (c.Request.Method == Get || c.Request.Method == Head) && !c.GetBool(noStoreKey) {
+			cfg.setValidators(c, resource, r)
+			cfg.cacheStatus(c, false)
+		}
+	}
+	if cfg.Stats != nil {
+		cfg.recordStats(c, e.Status == http.StatusNotModified)
+	}
+	e = cfg.observe(c, e, err)
+	cfg.callback(c, e)
+	if handled {
+		c.AbortWithStatus(e.Status)
+	}
+	return handled, err
+}
+
+// The client's partial copy is stale, so it gets the full representation
+// along with the current validators. Range is removed from the request, so
+// handlers serving the body themselves, through c.File or
+// http.ServeContent, also send all of it.
+func (cfg *Config) rangeFallback(c *gin.Context, resource interface{}, r resolved) {
+	c.Set(rangeIgnoredKey, true)
+	c.Request.Header.Del(Range)
+	cfg.setValidators(c, resource, r)
+}
+
+// Returns the status to abort with for an error returned by Conditional,
+// or 0 when the request should proceed.
+func errorStatus(err error) int {
+	if err == nil {
+		return 0
+	}
+
+	status := sentinelStatus(err)
+	var e *Error
+	if errors.As(err, &e) {
+		status = e.Status
+	}
+	if status == http.StatusOK {
+		return 0
+	}
+	return status
+}
+
+// Evaluates the preconditions, recording the header that decided the
+// outcome in e. A handled request is left for the caller to answer with
+// e.Status.
+func (cfg *Config) evaluate(c *gin.Context, resource interface{}, r resolved, e *Evaluation) (bool, error) {
+	// Most requests carry no precondition, nothing needs parsing,
+	// comparing or even probing the resource for.
+	if !cfg.conditionalRequest(c.Request) {
+		return false, nil
+	}
+	if resourceAbsent(resource, r) {
+		return cfg.absent(c, e)
+	}
+
+	// The validators are the request's memos, so every precondition, and
+	// the headers set afterwards, share one call to Etag and LastModified.
+	r.lenient = cfg.LenientEtags
+	v := &r
+	canCheckEtag := r.etag != nil
+	// An unknown modification date cannot be compared against.
+	canCheckModifier := r.modified != nil && !r.modified.LastModified().IsZero()
+
+	ifMatch, err := cfg.etags(c.Request, IfMatch, e)
+	if err != nil {
+		e.Header = IfMatch
+		return false, err
+	}
+	ifNoneMatch, err := cfg.etags(c.Request, IfNoneMatch, e)
+	if err != nil {
+		e.Header = IfNoneMatch
+		return false, err
+	}
+
+	if handled, err := cfg.unsupported(c, canCheckEtag, canCheckModifier); handled || err != nil {
+		if handled {
+			e.Status = http.StatusPreconditionFailed
+		}
+		return handled, err
+	}
 
-	if header := c.Request.Header.Get(IfMatch); canCheckEtag && header != "" {
+	if canCheckEtag && ifMatch.present() {
+		e.Header, e.Comparison = IfMatch, StrongComparison
 
 		// Does the request have an If-Match header?
-		if handleIfMatch(etagger, header) == false {
+		match, err := handleIfMatch(v, ifMatch)
+		if err != nil {
+			return false, err
+		}
+		if match == false {
 			return false, ErrWasModified
 		}
 
-	} else if header := c.Request.Header.Get(IfUnmodifiedSince); canCheckModifier && header != "" {
+	} else if header := cfg.header(c.Request, IfUnmodifiedSince); canCheckModifier && header != "" {
+		e.Header, e.Comparison = IfUnmodifiedSince, DateComparison
 
 		// Does the request have an If-Unmodified-Since header?
-		if handleIfUnmodifiedSince(modifier, header) == false {
+		date, err := cfg.parseDate(e, IfUnmodifiedSince, header)
+		if err != nil {
+			return false, err
+		}
+		if !date.IsZero() && handleIfUnmodifiedSince(v, date, cfg.ClockSkew) == false {
 			return false, ErrWasModified
 		}
 
 	}
 
-	if header := c.Request.Header.Get(IfNoneMatch); canCheckEtag && header != "" {
+	if canCheckEtag && ifNoneMatch.present() {
+		e.Header, e.Comparison = IfNoneMatch, WeakComparison
 
 		// Does the request have an If-None-Match header?
-		if handleIfNoneMatch(etagger, header) == false {
-			if c.Request.Method == Get || c.Request.Method == Head {
-				c.AbortWithStatus(http.StatusNotModified)
+		proceed, err := handleIfNoneMatch(v, ifNoneMatch)
+		if err != nil {
+			return false, err
+		}
+		if proceed == false {
+			if c.Request.Method != Get && c.Request.Method != Head {
+				e.Status = http.StatusPreconditionFailed
 				return true, nil
-			} else {
-				c.AbortWithStatus(http.StatusPreconditionFailed)
+			}
+			// Responses that are never stored are never revalidated.
+			if !c.GetBool(noStoreKey) {
+				e.Status = http.StatusNotModified
 				return true, nil
 			}
 		}
 
-	} else if c.Request.Method != Get || c.Request.Method != Head {
+	} else if c.Request.Method != Get && c.Request.Method != Head {
 		return false, nil
-	} else if header := c.Request.Header.Get(IfModifiedSince); canCheckModifier && header != "" {
-		if handleIfModifiedSince(modifier, header) == false {
-			c.AbortWithStatus(http.StatusNotModified)
+	} else if header := cfg.header(c.Request, IfModifiedSince); canCheckModifier && header != "" && !c.GetBool(noStoreKey) {
+		e.Header, e.Comparison = IfModifiedSince, DateComparison
+		date, err := cfg.parseDate(e, IfModifiedSince, header)
+		if err != nil {
+			return false, err
+		}
+		if !date.IsZero() && handleIfModifiedSince(v, date, cfg.ClockSkew) == false {
+			e.Status = http.StatusNotModified
 			return true, nil
 		}
 	}
 
-	if header := c.Request.Header.Get(IfRange); c.Request.Method == Get &&
+	// HEAD is evaluated like GET, so a client probing for range support
+	// gets the same decision without a body.
+	if header := cfg.header(c.Request, IfRange); !cfg.NoRanges && (c.Request.Method == Get || c.Request.Method == Head) &&
 		c.Request.Header.Get(Range) != "" && header != "" {
-		if handleIfRange(etagger, header) == false {
-			return false, ErrRangeMismatch
+		e.Header = IfRange
+		if cfg.LenientEtags && !isDate(header) {
+			header = normalizeEtag(header)
+		}
+
+		if isEntityTag(header) {
+			e.Comparison = StrongComparison
+			if !canCheckEtag {
+				return false, ErrRangeMismatch
+			}
+			match, err := handleIfRangeEtag(v, header)
+			if err != nil {
+				return false, err
+			}
+			if match == false {
+				return false, ErrRangeMismatch
+			}
+		} else {
+			e.Comparison = DateComparison
+			date, err := cfg.parseDate(e, IfRange, header)
+			if err != nil {
+				return false, err
+			}
+			// Unlike other preconditions, an ignored malformed If-Range
+			// cannot fall back to its absence: it matches nothing, so the
+			// full representation is sent.
+			if date.IsZero() || !canCheckModifier ||
+				handleIfRangeDate(v, date, responseDate(c.Writer.Header())) == false {
+				return false, ErrRangeMismatch
+			}
 		}
 	}
 
 	return false, nil
 }
 
-// Implements the Section 3.1 from RFC7232
-// https://tools.ietf.org/html/rfc7232#section-3.1
-func handleIfMatch(resource Etagger, clientEtag string) bool {
-	serverEtag, err := resource.Etag()
-	if err != nil && err != ErrNoResource {
-		return false
+// Reports whether the request carries any precondition, under its
+// standard name or an alias.
+func (cfg *Config) conditionalRequest(r *http.Request) bool {
+	for _, name := range clientValidators {
+		if len(cfg.values(r, name)) > 0 {
+			return true
+		}
 	}
+	return false
+}
 
-	if clientEtag == "*" && err == ErrNoResource {
-		return false
+// Implements the Section 3.1 from RFC7232. An error computing the ETag
+// decides nothing and is returned.
+// https://tools.ietf.org/html/rfc7232#section-3.1
+func handleIfMatch(resource *resolved, clientEtags etagList) (bool, error) {
+	serverEtag, err := resource.Etag()
+	if err != nil {
+		return false, validatorFailed(err)
 	}
 
-	if serverEtag == clientEtag || clientEtag == "*" {
-		return true
-	} else {
-		return false
-	}
+	return clientEtags.contains(serverEtag, strongMatch), nil
 }
 
 // Implements the Section 3.4 from RFC7232
 // https://tools.ietf.org/html/rfc7232#section-3.4
-func handleIfUnmodifiedSince(resource LastModifier, date string) bool {
-	clientDate, err := time.Parse(http.TimeFormat, date)
+func handleIfUnmodifiedSince(resource *resolved, clientDate time.Time, skew time.Duration) bool {
+	// HTTP-dates have a resolution of one second.
+	serverDate := resource.LastModified().Truncate(time.Second)
+	return unmodifiedSince(serverDate, clientDate, skew)
+}
+
+// Implements the Section 3.2 from RFC7232. An error computing the ETag
+// decides nothing and is returned.
+// https://tools.ietf.org/html/rfc7232#section-3.2
+func handleIfNoneMatch(resource *resolved, clientEtags etagList) (bool, error) {
+	serverEtag, err := resource.Etag()
 	if err != nil {
-		// A recipient MUST ignore the If-Unmodified-Since header field if the
-		// received field-value is not a valid HTTP-date.
-		return false
+		return false, validatorFailed(err)
 	}
 
-	serverDate := resource.LastModified()
-	if clientDate.Before(serverDate) {
+	return !clientEtags.contains(serverEtag, weakMatch), nil
+}
+
+// Implements the Section 3.3 from RFC7232
+// https://tools.ietf.org/html/rfc7232#section-3.3
+func handleIfModifiedSince(resource *resolved, clientDate time.Time, skew time.Duration) bool {
+	// A date which is later than the server's current time is invalid,
+	// and the request continues as if the header was absent.
+	if clientDate.After(time.Now().Add(skew)) {
 		return true
 	}
 
-	return false
+	serverDate := resource.LastModified().Truncate(time.Second)
+	return !unmodifiedSince(serverDate, clientDate, skew)
 }
 
-// Implements the Section 3.2 from RFC7232
-// https://tools.ietf.org/html/rfc7232#section-3.2
-func handleIfNoneMatch(resource Etagger, clientEtag string) bool {
+// Reports whether a resource last modified at serverDate is unchanged
+// since clientDate. A client date less than skew after the modification
+// may come from a fast clock and really predate it, so only a date echoed
+// from Last-Modified, or one at least skew later, confirms the client's
+// state.
+func unmodifiedSince(serverDate, clientDate time.Time, skew time.Duration) bool {
+	return serverDate.Equal(clientDate) || !serverDate.After(clientDate.Add(-skew))
+}
+
+// Implements the Section 3.2 from RFC7233, for an entity-tag. Only a
+// strong comparison can succeed.
+// https://tools.ietf.org/html/rfc7233#section-3.2
+func handleIfRangeEtag(resource *resolved, clientEtag string) (bool, error) {
 	serverEtag, err := resource.Etag()
 	if err != nil {
-		if clientEtag == "*" && err == ErrNoResource {
-			return true
-		}
-		return false
+		return false, validatorFailed(err)
 	}
 
-	if clientEtag == serverEtag {
+	return strongMatch(serverEtag, clientEtag), nil
+}
+
+// Implements the Section 3.2 from RFC7233, for an HTTP-date. The date must
+// match a Last-Modified that is itself a strong validator.
+// https://tools.ietf.org/html/rfc7233#section-3.2
+func handleIfRangeDate(resource *resolved, clientDate, date time.Time) bool {
+	serverDate := resource.LastModified().Truncate(time.Second)
+	if !StrongLastModified(serverDate, date) {
 		return false
 	}
 
-	return true
+	return serverDate.Equal(clientDate)
 }
 
-// Implements the Section 3.3 from RFC7232
-// https://tools.ietf.org/html/rfc7232#section-3.3
-func handleIfModifiedSince(resource LastModifier, date string) bool {
-	clientDate, err := time.Parse(http.TimeFormat, date)
-	if err != nil {
-		return false
-	}
+// StrongLastModified reports whether lastModified can serve as a strong
+// validator for a response sent at date. A Last-Modified within one second
+// of the response Date is weak, since the resource could have changed
+// again within the same second.
+// https://www.rfc-editor.org/rfc/rfc9110#section-8.8.2.2
+func StrongLastModified(lastModified, date time.Time) bool {
+	return !lastModified.Truncate(time.Second).After(date.Add(-time.Second))
+}
 
-	serverDate := resource.LastModified()
+// Returns the Date of the response being built, or the current time when
+// the handler has not set one.
+func responseDate(header http.Header) time.Time {
+	if date, err := http.ParseTime(header.Get("Date")); err == nil {
+		return date
+	}
+	return time.Now()
+}
 
-	// A date which is later than the server's current time is invalid.
-	// If the header is earlier than the current date, request should continue
-	if clientDate.After(time.Now()) || clientDate.Before(serverDate) {
+// Entity-tags are told apart up front, failed parses allocate errors.
+func isDate(value string) bool {
+	if isEntityTag(value) {
 		return false
 	}
-
-	return true
+	_, err := parseHTTPDate(value)
+	return err == nil
 }
 
-func handleIfRange(resource Etagger, clientEtag string) bool {
-
-	return false
+// If-Range carries either an entity-tag or an HTTP-date.
+func isEntityTag(value string) bool {
+	return strings.HasPrefix(value, `"`) || isWeak(value)
 }
@@ -0,0 +1,269 @@
+package conditional
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Runs Conditional for a request carrying header, returning the recorded
+// response along with Conditional's results.
+func runConditional(cfg *Config, method string, header map[string]string, resource interface{}) (*httptest.ResponseRecorder, bool, error) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(method, "/", nil)
+	for name, value := range header {
+		c.Request.Header.Set(name, value)
+	}
+	handled, err := cfg.Conditional(c, resource)
+	c.Writer.WriteHeaderNow()
+	return w, handled, err
+}
+
+var errBackend = errors.New("database timeout")
+
+type failingEtagger struct{}
+
+func (failingEtagger) Etag() (string, error) {
+	return "", errBackend
+}
+
+func TestEtagErrorsDecideNothing(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		header map[string]string
+	}{
+		{"if-none-match", Get, map[string]string{IfNoneMatch: `"stale"`}},
+		{"if-none-match star", Get, map[string]string{IfNoneMatch: "*"}},
+		{"if-none-match put", Put, map[string]string{IfNoneMatch: `"stale"`}},
+		{"if-match", Put, map[string]string{IfMatch: `"current"`}},
+		{"if-range", Get, map[string]string{Range: "bytes=0-1", IfRange: `"current"`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, handled, err := runConditional(&Config{}, tt.method, tt.header, failingEtagger{})
+			if handled {
+				t.Errorf("handled with %d", w.Code)
+			}
+			if !errors.Is(err, errBackend) || !errors.Is(err, ErrValidatorFailed) {
+				t.Errorf("err = %v, want the Etag error as ErrValidatorFailed", err)
+			}
+			if errors.Is(err, ErrWasModified) || errors.Is(err, ErrRangeMismatch) {
+				t.Errorf("err = %v decides the precondition", err)
+			}
+			if status := StatusOf(err); status != http.StatusInternalServerError {
+				t.Errorf("StatusOf = %d, want 500", status)
+			}
+			if etag := w.Header().Get(ETag); etag != "" {
+				t.Errorf("ETag %q set", etag)
+			}
+		})
+	}
+}
+
+func TestConditional(t *testing.T) {
+	modified := time.Date(2001, 9, 9, 1, 46, 40, 0, time.UTC)
+	resource := BytesResource(nil, `"a"`, modified)
+	before := modified.Add(-time.Hour).Format(http.TimeFormat)
+	after := modified.Add(time.Hour).Format(http.TimeFormat)
+
+	tests := []struct {
+		name    string
+		method  string
+		header  map[string]string
+		handled bool
+		status  int
+		err     error
+	}{
+		{"plain", Get, nil, false, http.StatusOK, nil},
+		{"if-none-match hit", Get, map[string]string{IfNoneMatch: `"a"`}, true, http.StatusNotModified, nil},
+		{"if-none-match weak hit", Get, map[string]string{IfNoneMatch: `W/"a"`}, true, http.StatusNotModified, nil},
+		{"if-none-match miss", Get, map[string]string{IfNoneMatch: `"b"`}, false, http.StatusOK, nil},
+		{"if-none-match star", Get, map[string]string{IfNoneMatch: "*"}, true, http.StatusNotModified, nil},
+		{"if-none-match put", Put, map[string]string{IfNoneMatch: `"a"`}, true, http.StatusPreconditionFailed, nil},
+		{"if-match hit", Put, map[string]string{IfMatch: `"a"`}, false, http.StatusOK, nil},
+		{"if-match weak", Put, map[string]string{IfMatch: `W/"a"`}, false, http.StatusOK, ErrWasModified},
+		{"if-match miss", Put, map[string]string{IfMatch: `"b"`}, false, http.StatusOK, ErrWasModified},
+		{"if-modified-since before", Get, map[string]string{IfModifiedSince: before}, false, http.StatusOK, nil},
+		{"if-modified-since after", Get, map[string]string{IfModifiedSince: after}, true, http.StatusNotModified, nil},
+		{"if-modified-since ignored by if-none-match", Get, map[string]string{IfNoneMatch: `"b"`, IfModifiedSince: after}, false, http.StatusOK, nil},
+		{"if-unmodified-since before", Put, map[string]string{IfUnmodifiedSince: before}, false, http.StatusOK, ErrWasModified},
+		{"if-unmodified-since after", Put, map[string]string{IfUnmodifiedSince: after}, false, http.StatusOK, nil},
+		{"if-range hit", Get, map[string]string{Range: "bytes=0-1", IfRange: `"a"`}, false, http.StatusOK, nil},
+		{"if-range miss", Get, map[string]string{Range: "bytes=0-1", IfRange: `"b"`}, false, http.StatusOK, ErrRangeMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, handled, err := runConditional(&Config{}, tt.method, tt.header, resource)
+			if handled != tt.handled || w.Code != tt.status {
+				t.Errorf("got handled %v with %d, want %v with %d", handled, w.Code, tt.handled, tt.status)
+			}
+			if !errors.Is(err, tt.err) || (tt.err == nil && err != nil) {
+				t.Errorf("err = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestClockSkew(t *testing.T) {
+	modified := time.Now().Add(-time.Hour).Truncate(time.Second)
+	resource := BytesResource(nil, "", modified)
+	date := func(d time.Duration) string {
+		return modified.Add(d).Format(http.TimeFormat)
+	}
+
+	tests := []struct {
+		name    string
+		method  string
+		header  string
+		offset  time.Duration
+		handled bool
+		err     error
+	}{
+		// The resource changed 3s after the client's date.
+		{"if-unmodified-since changed", Put, IfUnmodifiedSince, -3 * time.Second, false, ErrWasModified},
+		{"if-modified-since changed", Get, IfModifiedSince, -3 * time.Second, false, nil},
+		// The client's date is 3s after the change, within the skew.
+		{"if-unmodified-since within skew", Put, IfUnmodifiedSince, 3 * time.Second, false, ErrWasModified},
+		{"if-modified-since within skew", Get, IfModifiedSince, 3 * time.Second, false, nil},
+		// Echoed Last-Modified values and dates past the skew still match.
+		{"if-unmodified-since echoed", Put, IfUnmodifiedSince, 0, false, nil},
+		{"if-modified-since echoed", Get, IfModifiedSince, 0, true, nil},
+		{"if-unmodified-since past skew", Put, IfUnmodifiedSince, 10 * time.Second, false, nil},
+		{"if-modified-since past skew", Get, IfModifiedSince, 10 * time.Second, true, nil},
+	}
+	cfg := &Config{ClockSkew: 5 * time.Second}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, handled, err := runConditional(cfg, tt.method, map[string]string{tt.header: date(tt.offset)}, resource)
+			if handled != tt.handled || !errors.Is(err, tt.err) || (tt.err == nil && err != nil) {
+				t.Errorf("got handled %v, %v, want %v, %v", handled, err, tt.handled, tt.err)
+			}
+		})
+	}
+}
+
+func TestClockSkewFutureDates(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	resource := BytesResource(nil, "", now.Add(-time.Hour))
+	ahead := now.Add(3 * time.Second).Format(http.TimeFormat)
+
+	if _, handled, _ := runConditional(&Config{}, Get, map[string]string{IfModifiedSince: ahead}, resource); handled {
+		t.Error("future If-Modified-Since honoured without ClockSkew")
+	}
+	if _, handled, _ := runConditional(&Config{ClockSkew: 5 * time.Second}, Get, map[string]string{IfModifiedSince: ahead}, resource); !handled {
+		t.Error("If-Modified-Since within ClockSkew of now ignored")
+	}
+}
+
+func TestStrongLastModified(t *testing.T) {
+	date := time.Date(2001, 9, 9, 1, 46, 40, 0, time.UTC)
+
+	tests := []struct {
+		name         string
+		lastModified time.Time
+		strong       bool
+	}{
+		{"a second before", date.Add(-time.Second), true},
+		{"long before", date.Add(-time.Hour), true},
+		// Compared at the one second resolution of HTTP dates.
+		{"earlier in the previous second", date.Add(-500 * time.Millisecond), true},
+		{"same second", date, false},
+		{"later in the same second", date.Add(500 * time.Millisecond), false},
+		{"after", date.Add(time.Second), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := StrongLastModified(tt.lastModified, date); got != tt.strong {
+				t.Errorf("got %v, want %v", got, tt.strong)
+			}
+		})
+	}
+}
+
+func TestIsDate(t *testing.T) {
+	for _, tt := range []struct {
+		value string
+		date  bool
+	}{
+		{"Sun, 09 Sep 2001 01:46:40 GMT", true},
+		{"Sunday, 09-Sep-01 01:46:40 GMT", true},
+		{"Sun Sep  9 01:46:40 2001", true},
+		{`"a"`, false},
+		{`W/"a"`, false},
+		{"yesterday", false},
+		{"", false},
+	} {
+		if got := isDate(tt.value); got != tt.date {
+			t.Errorf("isDate(%q) = %v, want %v", tt.value, got, tt.date)
+		}
+	}
+}
+
+func TestResponseDate(t *testing.T) {
+	date := time.Date(2001, 9, 9, 1, 46, 40, 0, time.UTC)
+	if got := responseDate(http.Header{"Date": {date.Format(http.TimeFormat)}}); !got.Equal(date) {
+		t.Errorf("got %v, want the Date header %v", got, date)
+	}
+
+	for _, header := range []http.Header{{}, {"Date": {"soon"}}} {
+		before := time.Now()
+		if got := responseDate(header); got.Before(before) || got.After(time.Now()) {
+			t.Errorf("%v: got %v, want now", header, got)
+		}
+	}
+}
+
+func TestIfRangeDate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	modified := time.Date(2001, 9, 9, 1, 46, 40, 0, time.UTC)
+	r := gin.New()
+	r.GET("/", func(c *gin.Context) {
+		if date := c.Query("date"); date != "" {
+			c.Header("Date", date)
+		}
+		lm := modified
+		if c.Query("subsecond") != "" {
+			lm = lm.Add(300 * time.Millisecond)
+		}
+		Serve(c, BytesResource([]byte("0123456789"), "", lm))
+	})
+	r.GET("/undated", func(c *gin.Context) {
+		Serve(c, BytesResource([]byte("0123456789"), `"a"`, time.Time{}))
+	})
+
+	format := func(t time.Time) string { return t.Format(http.TimeFormat) }
+	tests := []struct {
+		name    string
+		path    string
+		ifRange string
+		status  int
+	}{
+		{"matching date", "/", format(modified), http.StatusPartialContent},
+		{"older date", "/", format(modified.Add(-time.Hour)), http.StatusOK},
+		{"newer date", "/", format(modified.Add(time.Hour)), http.StatusOK},
+		{"sub-second modification", "/?subsecond=1", format(modified), http.StatusPartialContent},
+		{"sent a second later", "/?date=" + url.QueryEscape(format(modified.Add(time.Second))), format(modified), http.StatusPartialContent},
+		// Modified in the second the response is sent, so it may change
+		// again within the same second: weak, and If-Range needs strong.
+		{"sent the same second", "/?date=" + url.QueryEscape(format(modified)), format(modified), http.StatusOK},
+		{"sent before", "/?date=" + url.QueryEscape(format(modified.Add(-time.Hour))), format(modified), http.StatusOK},
+		{"no last-modified", "/undated", format(modified), http.StatusOK},
+		{"malformed date", "/", "yesterday", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := request(r, Get, tt.path, map[string]string{Range: "bytes=0-1", IfRange: tt.ifRange})
+			if w.Code != tt.status {
+				t.Errorf("got %d, want %d", w.Code, tt.status)
+			}
+		})
+	}
+}
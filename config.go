@@ -0,0 +1,392 @@
+package conditional
+
+import (
+	"errors"
+	"fmt"
+	"hash"
+	"log"
+	"log/slog"
+	"reflect"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MalformedPolicy decides what happens to a conditional header whose value
+// cannot be parsed.
+type MalformedPolicy int
+
+const (
+	// Ignore the header as if it was absent, as RFC7232 requires.
+	IgnoreMalformed MalformedPolicy = iota
+
+	// Log the header through Config.ErrorLog, then ignore it.
+	LogMalformed
+
+	// Fail the evaluation with ErrMalformedHeader.
+	RejectMalformed
+)
+
+// UnsupportedPolicy decides what happens when a request carries a
+// precondition the resource implements no validator for, such as If-Match
+// on a resource that is only a LastModifier.
+type UnsupportedPolicy int
+
+const (
+	// Skip the precondition as if it was absent.
+	SkipUnsupported UnsupportedPolicy = iota
+
+	// Abort with 412 (Precondition Failed).
+	FailUnsupported
+
+	// Return ErrUnsupportedPrecondition to the caller.
+	ErrorUnsupported
+)
+
+// Config holds the options used when evaluating conditional requests.
+// The zero value follows the RFCs and is ready to use.
+type Config struct {
+	// Applied to every conditional header that fails to parse.
+	Malformed MalformedPolicy
+
+	// Applied to If-Match, If-Unmodified-Since, and If-None-Match on
+	// unsafe methods, when the resource cannot evaluate them. Skipping
+	// these is dangerous on write endpoints.
+	Unsupported UnsupportedPolicy
+
+	// Accept legacy unquoted entity-tags such as "If-None-Match: abc123",
+	// quoting them and trimming whitespace before comparison. Strict RFC
+	// parsing is used otherwise.
+	LenientEtags bool
+
+	// Logger for malformed headers, the log package's standard logger
+	// when nil.
+	ErrorLog *log.Logger
+
+	// Tolerance for client clocks running ahead of the server. Dates up
+	// to ClockSkew in the future are still accepted. Comparisons with
+	// Last-Modified only get stricter: a client date within ClockSkew
+	// after it, unless equal, counts as predating the modification.
+	ClockSkew time.Duration
+
+	// Leave setting ETag and Last-Modified to the handler, on 304s and on
+	// responses that proceed alike.
+	OmitValidators bool
+
+	// Told about every resource changed through GuardBuilder routes or
+	// the Sidecar, see ResourceChanged.
+	Invalidators []Invalidator
+
+	// Response header CheckHeaders adds its warnings to, instead of
+	// logging them.
+	WarningHeader string
+
+	// Name this layer reports revalidations under in Cache-Status, such
+	// as "gin-conditional". No Cache-Status is emitted when empty.
+	CacheStatus string
+
+	// Told about every evaluation, see Observer.
+	Observers []Observer
+
+	// Logs every evaluation at Debug level: the request, the client's and
+	// the resource's validators, and the outcome.
+	Logger *slog.Logger
+
+	// Measure the time the resource's Etag and LastModified take, which
+	// often query a database, and report it as Evaluation.ValidatorTime.
+	TimeValidators bool
+
+	// Answer requests carrying "X-Conditional-Debug: 1" with an
+	// X-Conditional-Trace header describing the evaluation, see Trace.
+	// This discloses validators, only enable it where clients may see them.
+	Debug bool
+
+	// Lifecycle callbacks run by Conditional once it decided, before it
+	// answers 304 or 412 or lets the request proceed to be served, for
+	// custom headers, auditing or counters. 412s the caller answers, for
+	// errors like ErrWasModified, are included.
+	OnNotModified        func(c *gin.Context, e Evaluation)
+	OnPreconditionFailed func(c *gin.Context, e Evaluation)
+	OnServed             func(c *gin.Context, e Evaluation)
+
+	// Custom request headers evaluated as standard preconditions.
+	Aliases []HeaderAlias
+
+	// Honour X-Forwarded-Host, -Prefix and -Proto when computing the
+	// canonical request target. Only enable behind a proxy that sets them.
+	TrustForwarded bool
+
+	// Emit Content-Location with the canonical target on 304 and stored
+	// responses, so caches behind rewriting proxies store the right URL.
+	ContentLocation bool
+
+	// Upper bound on the ranges served for one request, after overlapping
+	// and adjacent ranges are merged. Unlimited when zero.
+	MaxRanges int
+
+	// Answer requests over MaxRanges with 416 instead of a full 200.
+	RejectExcessRanges bool
+
+	// Serve full representations only, advertising "Accept-Ranges: none".
+	// Range and If-Range are ignored.
+	NoRanges bool
+
+	// Stream bodies served by the package in chunks of this many bytes,
+	// stopping when the request is cancelled. A single copy when zero.
+	ChunkSize int
+
+	// Send *os.File content straight to the connection, letting the
+	// runtime use sendfile for large files. This writes past any
+	// gin.ResponseWriter wrapper, so only enable it when no middleware
+	// transforms or counts bodies; gin's own Size is not updated either.
+	// Ignored with a ChunkSize.
+	Sendfile bool
+
+	// Content passed to ServeRange is already in its Content-Encoding,
+	// as with precompressed files, so ranges apply to it directly. When
+	// unset, ranges are not served while a Content-Encoding is active,
+	// since a compressing middleware would encode each part.
+	EncodedContent bool
+
+	// Bytes of a response AutoEtag holds in memory, DefaultBufferLimit
+	// when zero.
+	BufferLimit int64
+
+	// Let AutoEtag move bodies over BufferLimit to a temporary file in
+	// SpillDir, os.TempDir when empty, instead of passing them through
+	// without an ETag.
+	SpillToDisk bool
+	SpillDir    string
+
+	// Let AutoEtag hash bodies as they are written instead of once the
+	// handler returns. Bodies are only buffered up to the BufferLimit
+	// cutoff, so those under it still get an ETag and 304s, and larger
+	// ones are passed through without an ETag once they outgrow it.
+	StreamingHash bool
+
+	// Only let AutoEtag hash bodies up to this many bytes, unlimited when
+	// zero. Unlike BufferLimit this also applies with SpillToDisk.
+	AutoEtagMaxSize int64
+
+	// Content types AutoEtag hashes, such as "application/json" or
+	// "text/*". Anything but streams and binary downloads when empty.
+	AutoEtagTypes []string
+
+	// Bodies are modified after AutoEtag by middleware that does not set a
+	// Content-Encoding, such as minification, so generated ETags are
+	// emitted as weak.
+	TransformedBody bool
+
+	// Hash used for generated ETags, such as sha256.New, crc32.NewIEEE,
+	// fnv.New128a or an xxHash constructor. SHA-256 when nil. Digests
+	// longer than 16 bytes are truncated.
+	Hash func() hash.Hash
+
+	// Generate ETags as an HMAC of Hash under EtagKey, so clients cannot
+	// confirm a guess of a body's content from its ETag.
+	HMAC    bool
+	EtagKey []byte
+
+	// Text encoding of generated ETag digests.
+	HashEncoding EtagEncoding
+
+	// Mixed into every ETag the package hashes, typically a git SHA or
+	// build number, so all validators rotate at once on deploy. Metadata
+	// based ETags, like the nginx and S3 compatible ones, are unaffected.
+	EtagNamespace string
+
+	// Derives resource keys, TargetKey when nil.
+	Key KeyFunc
+
+	// Records per key revalidation outcomes when set.
+	Stats *KeyStats
+
+	shutdown shutdown
+	changes  changes
+}
+
+// DefaultConfig is used by the package level functions.
+var DefaultConfig = &Config{}
+
+func (cfg *Config) logf(format string, args ...interface{}) {
+	if cfg.ErrorLog != nil {
+		cfg.ErrorLog.Printf(format, args...)
+	} else {
+		log.Printf(format, args...)
+	}
+}
+
+func (cfg *Config) key(c *gin.Context) string {
+	if cfg.Key != nil {
+		return cfg.Key(c)
+	}
+	return cfg.TargetKey(c)
+}
+
+// Applies the unsupported precondition policy. It reports whether the
+// request is to be answered with 412, or an error for the caller.
+func (cfg *Config) unsupported(c *gin.Context, canCheckEtag, canCheckModifier bool) (bool, error) {
+	if cfg.Unsupported == SkipUnsupported {
+		return false, nil
+	}
+
+	unsafe := c.Request.Method != Get && c.Request.Method != Head
+	missing := (!canCheckEtag && cfg.header(c.Request, IfMatch) != "") ||
+		(!canCheckModifier && cfg.header(c.Request, IfUnmodifiedSince) != "") ||
+		(!canCheckEtag && unsafe && cfg.header(c.Request, IfNoneMatch) != "")
+	if !missing {
+		return false, nil
+	}
+
+	if cfg.Unsupported == ErrorUnsupported {
+		return false, ErrUnsupportedPrecondition
+	}
+	return true, nil
+}
+
+// Applies the malformed header policy, a nil error means the header is
+// to be ignored. The header is recorded in e, when evaluating, whatever the
+// policy.
+func (cfg *Config) malformed(e *Evaluation, name, value string) error {
+	if e != nil {
+		e.Malformed = append(e.Malformed, name)
+	}
+	switch cfg.Malformed {
+	case RejectMalformed:
+		return ErrMalformedHeader
+	case LogMalformed:
+		cfg.logf("conditional: ignoring malformed %s header %q", name, value)
+	}
+	return nil
+}
+
+// Parses an HTTP-date in any of the three formats recipients must accept.
+// A zero time with a nil error means the header is to be ignored.
+func (cfg *Config) parseDate(e *Evaluation, name, value string) (time.Time, error) {
+	date, err := parseHTTPDate(value)
+	if err != nil {
+		return time.Time{}, cfg.malformed(e, name, value)
+	}
+	return date, nil
+}
+
+// Validate reports incoherent option combinations, so they can be caught
+// at startup rather than misbehaving silently in production. Given the
+// engines cfg serves, it also checks their global middlewares for ones
+// marked with TransformsBody, whose output strong ETags would misrepresent.
+func (cfg *Config) Validate(engines ...*gin.Engine) error {
+	var errs []error
+
+	switch cfg.Malformed {
+	case IgnoreMalformed, LogMalformed, RejectMalformed:
+	default:
+		errs = append(errs, fmt.Errorf("conditional: unknown Malformed policy %d", cfg.Malformed))
+	}
+
+	switch cfg.Unsupported {
+	case SkipUnsupported, FailUnsupported, ErrorUnsupported:
+	default:
+		errs = append(errs, fmt.Errorf("conditional: unknown Unsupported policy %d", cfg.Unsupported))
+	}
+
+	if cfg.ClockSkew < 0 {
+		errs = append(errs, fmt.Errorf("conditional: ClockSkew must not be negative, got %s", cfg.ClockSkew))
+	}
+
+	if cfg.ChunkSize < 0 {
+		errs = append(errs, fmt.Errorf("conditional: ChunkSize must not be negative, got %d", cfg.ChunkSize))
+	}
+
+	if cfg.Sendfile && cfg.ChunkSize > 0 {
+		errs = append(errs, errors.New("conditional: Sendfile copies bodies in one go, it cannot be combined with ChunkSize"))
+	}
+
+	if cfg.BufferLimit < 0 {
+		errs = append(errs, fmt.Errorf("conditional: BufferLimit must not be negative, got %d", cfg.BufferLimit))
+	}
+	if cfg.SpillDir != "" && !cfg.SpillToDisk {
+		errs = append(errs, errors.New("conditional: SpillDir has no effect without SpillToDisk"))
+	}
+
+	if cfg.AutoEtagMaxSize < 0 {
+		errs = append(errs, fmt.Errorf("conditional: AutoEtagMaxSize must not be negative, got %d", cfg.AutoEtagMaxSize))
+	}
+
+	if cfg.StreamingHash && cfg.SpillToDisk {
+		errs = append(errs, errors.New("conditional: StreamingHash passes large bodies through, it cannot be combined with SpillToDisk"))
+	}
+
+	switch cfg.HashEncoding {
+	case HexEncoding, Base64Encoding:
+	default:
+		errs = append(errs, fmt.Errorf("conditional: unknown HashEncoding %d", cfg.HashEncoding))
+	}
+
+	if cfg.HMAC && len(cfg.EtagKey) == 0 {
+		errs = append(errs, errors.New("conditional: HMAC needs an EtagKey"))
+	}
+	if len(cfg.EtagKey) > 0 && !cfg.HMAC {
+		errs = append(errs, errors.New("conditional: EtagKey has no effect without HMAC"))
+	}
+
+	if cfg.TransformedBody && cfg.EncodedContent {
+		errs = append(errs, errors.New("conditional: EncodedContent promises served bytes are final, which contradicts TransformedBody"))
+	}
+	if !cfg.TransformedBody {
+		for _, engine := range engines {
+			for _, i := range transformingMiddlewares(engine) {
+				errs = append(errs, fmt.Errorf("conditional: global middleware %d transforms bodies after they are hashed, set TransformedBody so generated ETags are weak", i))
+			}
+		}
+	}
+
+	if cfg.MaxRanges < 0 {
+		errs = append(errs, fmt.Errorf("conditional: MaxRanges must not be negative, got %d", cfg.MaxRanges))
+	}
+	if cfg.RejectExcessRanges && cfg.MaxRanges == 0 {
+		errs = append(errs, errors.New("conditional: RejectExcessRanges has no effect without MaxRanges"))
+	}
+	if cfg.NoRanges && cfg.MaxRanges > 0 {
+		errs = append(errs, errors.New("conditional: MaxRanges has no effect with NoRanges"))
+	}
+
+	for _, alias := range cfg.Aliases {
+		switch alias.Standard {
+		case IfMatch, IfNoneMatch, IfModifiedSince, IfUnmodifiedSince:
+		case IfRange:
+			if cfg.NoRanges {
+				errs = append(errs, fmt.Errorf("conditional: alias %s enables If-Range, but NoRanges serves no ranges", alias.Request))
+			}
+		default:
+			errs = append(errs, fmt.Errorf("conditional: alias %s maps to %q, which is not a conditional header", alias.Request, alias.Standard))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// TransformsBody marks h as a middleware rewriting bodies without setting
+// a Content-Encoding, such as an HTML minifier, so Validate can tell the
+// engines using it need TransformedBody:
+//
+//	r.Use(conditional.TransformsBody(minify.Handler()))
+func TransformsBody(h gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		h(c)
+	}
+}
+
+// Every handler TransformsBody returns shares this code pointer.
+var transformsBody = reflect.ValueOf(TransformsBody(nil)).Pointer()
+
+// Returns the positions of the global middlewares of engine marked with
+// TransformsBody.
+func transformingMiddlewares(engine *gin.Engine) []int {
+	var positions []int
+	for i, h := range engine.Handlers {
+		if reflect.ValueOf(h).Pointer() == transformsBody {
+			positions = append(positions, i)
+		}
+	}
+	return positions
+}
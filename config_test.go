@@ -0,0 +1,93 @@
+package conditional
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func minifyHTML() gin.HandlerFunc {
+	return func(c *gin.Context) { c.Next() }
+}
+
+func TestValidate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	minified := gin.New()
+	minified.Use(gin.Recovery(), TransformsBody(minifyHTML()))
+	unmarked := gin.New()
+	unmarked.Use(minifyHTML())
+
+	tests := []struct {
+		name    string
+		cfg     *Config
+		engines []*gin.Engine
+		want    string
+	}{
+		{"zero", &Config{}, nil, ""},
+		{"strong ETags over a minifier", &Config{}, []*gin.Engine{minified}, "global middleware 1"},
+		{"weak ETags over a minifier", &Config{TransformedBody: true}, []*gin.Engine{minified}, ""},
+		{"plain engine", &Config{}, []*gin.Engine{gin.New()}, ""},
+		{"unmarked minifier", &Config{}, []*gin.Engine{unmarked}, ""},
+		{"If-Range without ranges", &Config{
+			NoRanges: true,
+			Aliases:  []HeaderAlias{{Request: "X-If-Range", Standard: IfRange}},
+		}, nil, "enables If-Range"},
+		{"If-Range with ranges", &Config{
+			Aliases: []HeaderAlias{{Request: "X-If-Range", Standard: IfRange}},
+		}, nil, ""},
+		{"HMAC without key", &Config{HMAC: true}, nil, "needs an EtagKey"},
+		{"HMAC with key", &Config{HMAC: true, EtagKey: []byte("secret")}, nil, ""},
+		{"key without HMAC", &Config{EtagKey: []byte("secret")}, nil, "without HMAC"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cfg.Validate(tt.engines...)
+			switch {
+			case tt.want == "" && err != nil:
+				t.Errorf("unexpected error: %v", err)
+			case tt.want != "" && (err == nil || !strings.Contains(err.Error(), tt.want)):
+				t.Errorf("got %v, want an error mentioning %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestHMACEtags(t *testing.T) {
+	plain := &Config{}
+	keyed := &Config{HMAC: true, EtagKey: []byte("one")}
+	rekeyed := &Config{HMAC: true, EtagKey: []byte("two")}
+
+	body := []byte("body")
+	if plain.EtagFromBytes(body) == keyed.EtagFromBytes(body) {
+		t.Error("HMAC ETag equals the plain hash")
+	}
+	if keyed.EtagFromBytes(body) == rekeyed.EtagFromBytes(body) {
+		t.Error("ETags do not depend on the key")
+	}
+	if keyed.EtagFromBytes(body) != keyed.EtagFromBytes(body) {
+		t.Error("HMAC ETags are not stable")
+	}
+}
+
+func TestNoRanges(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := &Config{NoRanges: true}
+	r := gin.New()
+	r.GET("/", Policy{Config: cfg}.Handler(), func(c *gin.Context) {
+		ServeRange(c, strings.NewReader("0123456789"), 10)
+	})
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(Get, "/", nil)
+	req.Header.Set(Range, "bytes=0-3")
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || w.Body.String() != "0123456789" {
+		t.Errorf("got %d %q, want the full body", w.Code, w.Body.String())
+	}
+	if got := w.Header().Get(AcceptRanges); got != "none" {
+		t.Errorf("Accept-Ranges = %q", got)
+	}
+}
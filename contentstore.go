@@ -0,0 +1,145 @@
+package conditional
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// An error returned when storing content under a weak ETag. Weak tags do
+// not promise byte-for-byte equality, so they cannot address a body.
+var ErrWeakEtag = errors.New("Content can only be addressed by a strong ETag")
+
+// Content is a full representation as it would be sent in a 200.
+type Content struct {
+	Header http.Header
+	Body   []byte
+}
+
+// ContentStore holds full response bodies keyed by their strong ETag, so
+// a gin instance can act as a small validating cache in front of an origin.
+type ContentStore interface {
+	Get(etag string) (Content, bool)
+	Put(etag string, content Content) error
+	Remove(etag string)
+}
+
+// MemoryContentStore is a ContentStore kept in process memory.
+type MemoryContentStore struct {
+	mu       sync.Mutex
+	entries  map[string]Content
+	capacity int
+	policy   EvictionPolicy
+}
+
+// NewMemoryContentStore returns an unbounded store.
+func NewMemoryContentStore() *MemoryContentStore {
+	return &MemoryContentStore{entries: make(map[string]Content)}
+}
+
+// NewBoundedContentStore returns a store holding at most capacity bodies,
+// evicting according to the policy newPolicy makes once full, LRU when nil.
+func NewBoundedContentStore(capacity int, newPolicy PolicyFactory) *MemoryContentStore {
+	if newPolicy == nil {
+		newPolicy = LRU
+	}
+	return &MemoryContentStore{
+		entries:  make(map[string]Content),
+		capacity: capacity,
+		policy:   newPolicy(capacity),
+	}
+}
+
+func (s *MemoryContentStore) Get(etag string) (Content, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	content, ok := s.entries[etag]
+	if ok && s.policy != nil {
+		s.policy.Accessed(etag)
+	}
+	return content, ok
+}
+
+func (s *MemoryContentStore) Put(etag string, content Content) error {
+	if isWeak(etag) {
+		return ErrWeakEtag
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.entries[etag]; !ok && s.policy != nil {
+		for len(s.entries) >= s.capacity {
+			victim, ok := s.policy.Victim()
+			if !ok {
+				break
+			}
+			delete(s.entries, victim)
+		}
+		s.policy.Added(etag)
+	}
+
+	s.entries[etag] = content
+	return nil
+}
+
+func (s *MemoryContentStore) Remove(etag string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.entries[etag]; ok && s.policy != nil {
+		s.policy.Removed(etag)
+	}
+	delete(s.entries, etag)
+}
+
+// ServeStored answers a GET or HEAD from store for the representation
+// currently identified by etag, evaluating the request's preconditions and
+// ranges against it like Serve. Clients already holding it get a 304 even
+// when the body is not in the store, otherwise it returns false without
+// writing anything then.
+func ServeStored(c *gin.Context, store ContentStore, etag string) bool {
+	return ConfigOf(c).ServeStored(c, store, etag)
+}
+
+func (cfg *Config) ServeStored(c *gin.Context, store ContentStore, etag string) bool {
+	if c.Request.Method != Get && c.Request.Method != Head {
+		return false
+	}
+
+	content, ok := store.Get(etag)
+	if !ok {
+		ifNoneMatch, err := cfg.etags(c.Request, IfNoneMatch, nil)
+		if err != nil || !ifNoneMatch.present() {
+			return false
+		}
+		if proceed, _ := handleIfNoneMatch(&resolved{etag: knownEtag(etag)}, ifNoneMatch); proceed {
+			return false
+		}
+		cfg.NotModified(c, etagValue(etag))
+		return true
+	}
+
+	header := c.Writer.Header()
+	for key, values := range content.Header {
+		header[key] = append([]string(nil), values...)
+	}
+	if cfg.ContentLocation && header.Get("Content-Location") == "" {
+		header.Set("Content-Location", cfg.CanonicalTarget(c.Request))
+	}
+
+	cfg.Serve(c, BytesResource(content.Body, etag, time.Time{}))
+	c.Abort()
+	return true
+}
+
+// etagValue is an Etagger for an already known ETag.
+type etagValue string
+
+func (e etagValue) Etag() (string, error) {
+	return string(e), nil
+}
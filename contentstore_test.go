@@ -0,0 +1,131 @@
+package conditional
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestServeStoredCopiesHeader(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store := NewMemoryContentStore()
+	store.Put(`"a"`, Content{
+		Body:   []byte("body"),
+		Header: http.Header{"Link": make([]string, 1, 2)},
+	})
+	r := gin.New()
+	r.GET("/", func(c *gin.Context) {
+		ServeStored(c, store, `"a"`)
+		c.Writer.Header().Add("Link", "</next>; rel=next")
+	})
+
+	for i := 0; i < 2; i++ {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(Get, "/", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("got %d", w.Code)
+		}
+	}
+	content, _ := store.Get(`"a"`)
+	if links := content.Header["Link"]; len(links) != 1 || links[:2][1] != "" {
+		t.Errorf("stored header changed by a response: %q", links[:cap(links)])
+	}
+}
+
+func TestServeStoredPreconditions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store := NewMemoryContentStore()
+	store.Put(`"a"`, Content{
+		Body:   []byte("0123456789"),
+		Header: http.Header{"Content-Type": {"text/plain"}},
+	})
+	r := gin.New()
+	serve := func(c *gin.Context) {
+		if !ServeStored(c, store, c.Query("etag")) {
+			c.String(http.StatusTeapot, "origin")
+		}
+	}
+	r.GET("/", serve)
+	r.HEAD("/", serve)
+
+	tests := []struct {
+		name   string
+		method string
+		etag   string
+		header map[string]string
+		status int
+		body   string
+	}{
+		{"plain", Get, `"a"`, nil, http.StatusOK, "0123456789"},
+		{"head", Head, `"a"`, nil, http.StatusOK, ""},
+		{"if-none-match", Get, `"a"`, map[string]string{IfNoneMatch: `"a"`}, http.StatusNotModified, ""},
+		{"if-none-match miss", Get, `"b"`, map[string]string{IfNoneMatch: `"b"`}, http.StatusNotModified, ""},
+		{"not stored", Get, `"b"`, nil, http.StatusTeapot, "origin"},
+		{"if-match", Get, `"a"`, map[string]string{IfMatch: `"x"`}, http.StatusPreconditionFailed, ""},
+		{"if-modified-since", Get, `"a"`, map[string]string{IfModifiedSince: "Sun, 09 Sep 2001 01:46:40 GMT"}, http.StatusOK, "0123456789"},
+		{"range", Get, `"a"`, map[string]string{Range: "bytes=2-4"}, http.StatusPartialContent, "234"},
+		{"if-range stale", Get, `"a"`, map[string]string{Range: "bytes=2-4", IfRange: `"x"`}, http.StatusOK, "0123456789"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(tt.method, "/?etag="+tt.etag, nil)
+			for name, value := range tt.header {
+				req.Header.Set(name, value)
+			}
+			r.ServeHTTP(w, req)
+			if w.Code != tt.status || w.Body.String() != tt.body {
+				t.Errorf("got %d %q, want %d %q", w.Code, w.Body.String(), tt.status, tt.body)
+			}
+			if tt.status == http.StatusOK && tt.etag == `"a"` {
+				if w.Header().Get(ETag) != `"a"` || w.Header().Get("Content-Type") != "text/plain" {
+					t.Errorf("headers %v", w.Header())
+				}
+			}
+		})
+	}
+}
+
+func TestBoundedContentStoreDefaultsToLRU(t *testing.T) {
+	store := NewBoundedContentStore(2, nil)
+	for _, etag := range []string{`"a"`, `"b"`, `"c"`} {
+		store.Put(etag, Content{Body: []byte(etag)})
+	}
+	if _, ok := store.Get(`"a"`); ok {
+		t.Error("store grew past its capacity")
+	}
+	if _, ok := store.Get(`"c"`); !ok {
+		t.Error("newest body evicted")
+	}
+}
+
+func TestBoundedContentStorePolicies(t *testing.T) {
+	tests := []struct {
+		name      string
+		newPolicy PolicyFactory
+		evicted   string
+	}{
+		// "a" is read before "b" is added, then "c" is added to a full
+		// store: recency alone evicts "a".
+		{"lru", LRU, `"a"`},
+		{"lfu", LFU, `"b"`},
+		{"arc", NewARC, `"b"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewBoundedContentStore(2, tt.newPolicy)
+			store.Put(`"a"`, Content{})
+			store.Get(`"a"`)
+			store.Put(`"b"`, Content{})
+			store.Put(`"c"`, Content{})
+
+			for _, etag := range []string{`"a"`, `"b"`, `"c"`} {
+				if _, ok := store.Get(etag); ok == (etag == tt.evicted) {
+					t.Errorf("%s stored = %v, want %s evicted", etag, ok, tt.evicted)
+				}
+			}
+		})
+	}
+}
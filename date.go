@@ -0,0 +1,39 @@
+package conditional
+
+import (
+	"hash/maphash"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Slots of the parsed date cache.
+const dateCacheSize = 1024
+
+// Clients resend the same If-Modified-Since for as long as they keep a
+// response, so parsed dates are cached by their raw value. The cache is
+// direct mapped: a date replaces whichever one shared its slot.
+var (
+	dateCache [dateCacheSize]atomic.Pointer[parsedDate]
+	dateSeed  = maphash.MakeSeed()
+)
+
+type parsedDate struct {
+	value string
+	date  time.Time
+}
+
+// Parses an HTTP-date like http.ParseTime, through the cache.
+func parseHTTPDate(value string) (time.Time, error) {
+	slot := &dateCache[maphash.String(dateSeed, value)%dateCacheSize]
+	if cached := slot.Load(); cached != nil && cached.value == value {
+		return cached.date, nil
+	}
+
+	date, err := http.ParseTime(value)
+	if err == nil {
+		slot.Store(&parsedDate{value: strings.Clone(value), date: date})
+	}
+	return date, err
+}
@@ -0,0 +1,98 @@
+package conditional
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Headers of the evaluation trace, see Config.Debug.
+const (
+	DebugHeader = "X-Conditional-Debug"
+	TraceHeader = "X-Conditional-Trace"
+)
+
+// The gin.Context key the Trace of a debugged request is stored under.
+const TraceKey = "conditional.trace"
+
+// Trace is the full account of an evaluation, sent to clients that ask
+// for it while debugging.
+type Trace struct {
+	// Conditional headers on the request, in evaluation order.
+	Headers []TracedHeader `json:"headers"`
+
+	// Validators of the resource, as set on the response.
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+
+	// The deciding header and how it was compared, empty when none did.
+	DecidedBy  string     `json:"decided_by,omitempty"`
+	Comparison Comparison `json:"comparison,omitempty"`
+
+	Outcome string `json:"outcome"`
+	Status  int    `json:"status,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// TracedHeader is a conditional header as the evaluator saw it.
+type TracedHeader struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+
+	// Whether the value parsed, malformed values are handled by the
+	// Config's Malformed policy.
+	Parsed bool `json:"parsed"`
+}
+
+// Attaches the trace of e when debugging is enabled and the request asks
+// for it with "X-Conditional-Debug: 1".
+func (cfg *Config) trace(c *gin.Context, e Evaluation) {
+	if !cfg.Debug || c.Request.Header.Get(DebugHeader) != "1" {
+		return
+	}
+
+	t := Trace{
+		ETag:       e.ETag,
+		DecidedBy:  e.Header,
+		Comparison: e.Comparison,
+		Outcome:    e.Outcome(),
+		Status:     e.Status,
+	}
+	if !e.LastModified.IsZero() {
+		t.LastModified = e.LastModified.UTC().Format(http.TimeFormat)
+	}
+	if e.Err != nil {
+		t.Error = e.Err.Error()
+	}
+	for _, name := range clientValidators {
+		values := cfg.values(c.Request, name)
+		if len(values) == 0 {
+			continue
+		}
+		t.Headers = append(t.Headers, TracedHeader{
+			Name:   name,
+			Value:  strings.Join(values, ", "),
+			Parsed: cfg.parses(name, values),
+		})
+	}
+
+	c.Set(TraceKey, t)
+	if value, err := json.Marshal(t); err == nil {
+		c.Writer.Header().Set(TraceHeader, string(value))
+	}
+}
+
+// Reports whether the values of a conditional header are well formed.
+func (cfg *Config) parses(name string, values []string) bool {
+	switch name {
+	case IfMatch, IfNoneMatch:
+		return etagList{values: values, lenient: cfg.LenientEtags}.valid()
+	case IfRange:
+		if value := values[0]; !isDate(value) && (isEntityTag(value) || cfg.LenientEtags) {
+			return etagList{values: values[:1], lenient: cfg.LenientEtags}.valid()
+		}
+	}
+	return isDate(values[0])
+}
@@ -0,0 +1,80 @@
+package conditional
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Error is the type of the errors returned by Conditional, and the
+// functions built on it, and by ParseRange. It wraps one of the package's
+// sentinel errors, which errors.Is still finds, along with the status to
+// answer with and the header that caused it:
+//
+//	handled, err := conditional.Conditional(c, resource)
+//	if status := conditional.StatusOf(err); status != 0 {
+//		c.AbortWithStatus(status)
+//	}
+type Error struct {
+	// Status suggested for the response, such as 412 or 416, or 200 when
+	// the full representation is to be served, as for ErrRangeMismatch.
+	Status int
+
+	// Request header that caused the error, empty when none did.
+	Header string
+
+	// The sentinel error, such as ErrWasModified.
+	Err error
+}
+
+func (e *Error) Error() string {
+	if e.Header == "" {
+		return e.Err.Error()
+	}
+	return e.Header + ": " + e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// StatusOf returns the status to answer with for an error returned by
+// Conditional, or 0 when the request should proceed.
+func StatusOf(err error) int {
+	return errorStatus(err)
+}
+
+// Wraps a sentinel error in an Error for header. Errors already carrying
+// a status are returned as they are.
+func wrapError(err error, header string) error {
+	var e *Error
+	if err == nil || errors.As(err, &e) {
+		return err
+	}
+	return &Error{Status: sentinelStatus(err), Header: header, Err: err}
+}
+
+// Returns the status the package's sentinel errors suggest.
+func sentinelStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrRangeMismatch), errors.Is(err, ErrInvalidRange):
+		return http.StatusOK
+	case errors.Is(err, ErrNoResource):
+		return http.StatusNotFound
+	case errors.Is(err, ErrMalformedHeader):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrWasModified), errors.Is(err, ErrUnsupportedPrecondition):
+		return http.StatusPreconditionFailed
+	case errors.Is(err, ErrRangeNotSatisfiable):
+		return http.StatusRequestedRangeNotSatisfiable
+	case errors.Is(err, ErrValidatorFailed):
+		return http.StatusInternalServerError
+	}
+	return http.StatusInternalServerError
+}
+
+// Marks an error returned by a resource's Etag as ErrValidatorFailed,
+// keeping it for errors.Is and errors.As.
+func validatorFailed(err error) error {
+	return fmt.Errorf("%w: %w", ErrValidatorFailed, err)
+}
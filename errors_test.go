@@ -0,0 +1,44 @@
+package conditional
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestStatusOf(t *testing.T) {
+	tests := []struct {
+		err    error
+		status int
+	}{
+		{nil, 0},
+		{wrapError(ErrWasModified, IfMatch), http.StatusPreconditionFailed},
+		{wrapError(ErrUnsupportedPrecondition, IfMatch), http.StatusPreconditionFailed},
+		{wrapError(ErrNoResource, ""), http.StatusNotFound},
+		{wrapError(ErrMalformedHeader, IfNoneMatch), http.StatusBadRequest},
+		{wrapError(ErrRangeMismatch, IfRange), 0},
+		{wrapError(ErrRangeNotSatisfiable, Range), http.StatusRequestedRangeNotSatisfiable},
+		{wrapError(validatorFailed(errBackend), IfNoneMatch), http.StatusInternalServerError},
+		{&Error{Status: http.StatusConflict, Err: ErrWasModified}, http.StatusConflict},
+		{errors.New("unknown"), http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		if status := StatusOf(tt.err); status != tt.status {
+			t.Errorf("StatusOf(%v) = %d, want %d", tt.err, status, tt.status)
+		}
+	}
+}
+
+func TestValidatorFailedError(t *testing.T) {
+	err := wrapError(validatorFailed(errBackend), IfMatch)
+	var e *Error
+	if !errors.As(err, &e) || e.Header != IfMatch {
+		t.Fatalf("err = %#v, want an Error for If-Match", err)
+	}
+	if !errors.Is(err, ErrValidatorFailed) || !errors.Is(err, errBackend) {
+		t.Errorf("%v does not wrap both errors", err)
+	}
+	if want := "If-Match: Computing the resource's validators failed: database timeout"; err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+}
@@ -0,0 +1,299 @@
+package conditional
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"hash"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Returns the entity-tags of an If-Match or If-None-Match header, across
+// all of its lines. An empty list means the header is absent, or malformed
+// and to be ignored. Values breaking the grammar, including "*" mixed
+// with entity-tags, go through the Malformed policy and are recorded in e
+// when it is not nil.
+func (cfg *Config) etags(r *http.Request, name string, e *Evaluation) (etagList, error) {
+	values := cfg.values(r, name)
+	if len(values) == 0 {
+		return etagList{}, nil
+	}
+
+	tags := etagList{values: values, lenient: cfg.LenientEtags}
+	if !tags.valid() {
+		return etagList{}, cfg.malformed(e, name, strings.Join(values, ", "))
+	}
+	return tags, nil
+}
+
+// etagList is an If-Match or If-None-Match header, kept as its field lines
+// and scanned in place, so evaluating it does not allocate. When lenient,
+// unquoted legacy tags are accepted and normalized.
+type etagList struct {
+	values  []string
+	lenient bool
+}
+
+func (l etagList) present() bool {
+	return l.values != nil
+}
+
+// Reports whether the lines follow the grammar "*" / #entity-tag.
+// https://tools.ietf.org/html/rfc7232#section-3.1
+func (l etagList) valid() bool {
+	n, wildcard := 0, false
+	s := etagScanner{values: l.values, lenient: l.lenient}
+	for s.next() {
+		n++
+		wildcard = wildcard || s.tag == "*"
+	}
+
+	// "*" must be the only member, mixes like `*, "abc"` are invalid.
+	return !s.malformed && n > 0 && !(wildcard && n > 1)
+}
+
+// Reports whether the list is "*" or holds a tag equal to etag by match.
+func (l etagList) contains(etag string, match func(a, b string) bool) bool {
+	s := etagScanner{values: l.values, lenient: l.lenient}
+	for s.next() {
+		if s.tag == "*" || match(etag, s.tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// Iterates over the entity-tags of header lines, stopping early on a
+// malformed one.
+type etagScanner struct {
+	values  []string
+	lenient bool
+
+	rest      string
+	tag       string
+	malformed bool
+}
+
+func (s *etagScanner) next() bool {
+	for {
+		s.rest = strings.TrimLeft(s.rest, " \t,")
+		if s.rest != "" {
+			break
+		}
+		if len(s.values) == 0 {
+			return false
+		}
+		s.rest, s.values = s.values[0], s.values[1:]
+	}
+
+	value := s.rest
+	if value[0] == '*' {
+		s.tag, value = "*", value[1:]
+	} else if s.lenient && !strings.HasPrefix(strings.TrimPrefix(value, "W/"), `"`) {
+		end := strings.IndexByte(value, ',')
+		if end < 0 {
+			end = len(value)
+		}
+		s.tag, value = normalizeEtag(value[:end]), value[end:]
+	} else {
+		var ok bool
+		if s.tag, value, ok = scanEtag(value); !ok {
+			s.malformed = true
+			return false
+		}
+	}
+
+	s.rest = strings.TrimLeft(value, " \t")
+	if s.rest != "" && s.rest[0] != ',' {
+		s.malformed = true
+		return false
+	}
+	return true
+}
+
+// Scans one entity-tag from the start of s, returning it and the rest.
+//
+//	entity-tag = [ weak ] opaque-tag
+//	weak       = %x57.2F ; "W/"
+//	opaque-tag = DQUOTE *etagc DQUOTE
+//	etagc      = %x21 / %x23-7E / obs-text
+func scanEtag(s string) (string, string, bool) {
+	start := 0
+	if strings.HasPrefix(s, "W/") {
+		start = 2
+	}
+	if len(s) < start+2 || s[start] != '"' {
+		return "", "", false
+	}
+
+	for i := start + 1; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '"':
+			return s[:i+1], s[i+1:], true
+		case c == 0x21, c >= 0x23 && c != 0x7f:
+		default:
+			return "", "", false
+		}
+	}
+	return "", "", false
+}
+
+// Quotes a legacy unquoted opaque tag, such as abc123 or W/abc123, and
+// trims surrounding whitespace.
+func normalizeEtag(etag string) string {
+	etag = strings.TrimSpace(etag)
+
+	weak := ""
+	if isWeak(etag) {
+		weak, etag = "W/", strings.TrimSpace(etag[2:])
+	}
+	if etag == "*" || strings.HasPrefix(etag, `"`) && strings.HasSuffix(etag, `"`) && len(etag) > 1 {
+		return weak + etag
+	}
+	return weak + `"` + strings.Trim(etag, `"`) + `"`
+}
+
+// EncodedEtag returns the ETag of the representation of etag encoded with
+// a Content-Encoding, such as "abc123-gzip" for "abc123". It mirrors what
+// Apache does, so identity and encoded representations never share a
+// validator.
+func EncodedEtag(etag, encoding string) string {
+	encoding = strings.ToLower(strings.TrimSpace(encoding))
+	if encoding == "" || encoding == "identity" || !strings.HasSuffix(etag, `"`) {
+		return etag
+	}
+	return etag[:len(etag)-1] + "-" + encoding + `"`
+}
+
+func isWeak(etag string) bool {
+	return strings.HasPrefix(etag, "W/")
+}
+
+// Two entity-tags are strongly equal when neither is weak and their
+// opaque-tags match character by character.
+// https://tools.ietf.org/html/rfc7232#section-2.3.2
+func strongMatch(a, b string) bool {
+	return !isWeak(a) && !isWeak(b) && a == b
+}
+
+// Two entity-tags are weakly equal when their opaque-tags match,
+// regardless of either being weak.
+func weakMatch(a, b string) bool {
+	return strings.TrimPrefix(a, "W/") == strings.TrimPrefix(b, "W/")
+}
+
+// EtagEncoding is the text encoding of digests in generated ETags.
+type EtagEncoding int
+
+const (
+	HexEncoding EtagEncoding = iota
+	Base64Encoding
+)
+
+// Digests are cut to this many bytes, which is plenty to tell
+// representations of one resource apart.
+const maxDigestSize = 16
+
+// EtagFromBytes returns a strong ETag naming the exact bytes of b, using
+// DefaultConfig's hash.
+func EtagFromBytes(b []byte) string {
+	return DefaultConfig.EtagFromBytes(b)
+}
+
+func (cfg *Config) EtagFromBytes(b []byte) string {
+	h := cfg.newHash()
+	h.Write(b)
+	return cfg.etagFromSum(h.Sum(nil))
+}
+
+// Returns a strong ETag naming the bytes read from r.
+func (cfg *Config) hashEtagReader(r io.Reader) (string, error) {
+	h := cfg.newHash()
+	if _, err := io.Copy(h, r); err != nil {
+		return "", err
+	}
+	return cfg.etagFromSum(h.Sum(nil)), nil
+}
+
+// Returns the hash for generated ETags, seeded with the EtagNamespace.
+func (cfg *Config) newHash() hash.Hash {
+	newHash := cfg.Hash
+	if newHash == nil {
+		newHash = sha256.New
+	}
+
+	var h hash.Hash
+	if cfg.HMAC {
+		h = hmac.New(newHash, cfg.EtagKey)
+	} else {
+		h = newHash()
+	}
+
+	if cfg.EtagNamespace != "" {
+		h.Write([]byte(cfg.EtagNamespace))
+		h.Write([]byte{0})
+	}
+	return h
+}
+
+// Formats a digest as a strong ETag.
+func (cfg *Config) etagFromSum(sum []byte) string {
+	if len(sum) > maxDigestSize {
+		sum = sum[:maxDigestSize]
+	}
+
+	if cfg.HashEncoding == Base64Encoding {
+		return `"` + base64.RawStdEncoding.EncodeToString(sum) + `"`
+	}
+	return `"` + hex.EncodeToString(sum) + `"`
+}
+
+// EtagFromReader hashes r into a strong ETag and returns a reader
+// replaying the same bytes, so streams such as generated exports are not
+// produced twice. Up to BufferLimit bytes are kept in memory, the rest in
+// a temporary file. The replay reader removes that file once read to EOF;
+// it is an io.Closer to release it earlier.
+func EtagFromReader(r io.Reader) (string, io.Reader, error) {
+	return DefaultConfig.EtagFromReader(r)
+}
+
+func (cfg *Config) EtagFromReader(r io.Reader) (string, io.Reader, error) {
+	limit := cfg.BufferLimit
+	if limit == 0 {
+		limit = DefaultBufferLimit
+	}
+	buf := NewSpillBuffer(limit, true)
+	buf.TempDir = cfg.SpillDir
+
+	etag, err := cfg.hashEtagReader(io.TeeReader(r, buf))
+	if err != nil {
+		buf.Close()
+		return "", nil, err
+	}
+	return etag, &replayReader{buf: buf, r: buf.ReadSeeker()}, nil
+}
+
+type replayReader struct {
+	buf  *SpillBuffer
+	r    io.Reader
+	done bool
+}
+
+func (r *replayReader) Read(p []byte) (int, error) {
+	if r.done {
+		return 0, io.EOF
+	}
+	n, err := r.r.Read(p)
+	if err == io.EOF {
+		r.done = true
+		r.buf.Close()
+	}
+	return n, err
+}
+
+func (r *replayReader) Close() error {
+	return r.buf.Close()
+}
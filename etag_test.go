@@ -0,0 +1,194 @@
+package conditional
+
+import (
+	"errors"
+	"io"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func scanTags(values ...string) ([]string, bool) {
+	var tags []string
+	s := etagScanner{values: values}
+	for s.next() {
+		tags = append(tags, s.tag)
+	}
+	return tags, !s.malformed
+}
+
+func TestEtagScanner(t *testing.T) {
+	for _, test := range []struct {
+		values []string
+		tags   []string
+		ok     bool
+	}{
+		{[]string{`"a"`}, []string{`"a"`}, true},
+		{[]string{`W/"a", "b"`}, []string{`W/"a"`, `"b"`}, true},
+		{[]string{`"a"`, `W/"b"`}, []string{`"a"`, `W/"b"`}, true},
+		{[]string{`*`}, []string{`*`}, true},
+		{[]string{`"a,b", "c"`}, []string{`"a,b"`, `"c"`}, true},
+		{[]string{` , "a" ,, `}, []string{`"a"`}, true},
+		{[]string{`"a`}, nil, false},
+		{[]string{`"a", "b`}, []string{`"a"`}, false},
+		{[]string{`W/a`}, nil, false},
+		{[]string{`"a" "b"`}, nil, false},
+		{[]string{`"a b"`}, nil, false},
+	} {
+		tags, ok := scanTags(test.values...)
+		if !reflect.DeepEqual(tags, test.tags) || ok != test.ok {
+			t.Errorf("%q: got %q, %v, want %q, %v", test.values, tags, ok, test.tags, test.ok)
+		}
+	}
+}
+
+func TestEtagListValid(t *testing.T) {
+	for value, valid := range map[string]bool{
+		`"a", W/"b"`: true,
+		`*`:          true,
+		`*, "a"`:     false,
+		`"a`:         false,
+		``:           false,
+	} {
+		if got := (etagList{values: []string{value}}).valid(); got != valid {
+			t.Errorf("%q: valid = %v, want %v", value, got, valid)
+		}
+	}
+}
+
+func TestEtagListContains(t *testing.T) {
+	list := etagList{values: []string{`W/"a", "b,c"`}}
+	if !list.contains(`"a"`, weakMatch) {
+		t.Error(`weak comparison of "a" failed`)
+	}
+	if list.contains(`"a"`, strongMatch) {
+		t.Error(`strong comparison of "a" with W/"a" succeeded`)
+	}
+	if !list.contains(`"b,c"`, strongMatch) {
+		t.Error(`comma inside quotes split the tag`)
+	}
+	if !(etagList{values: []string{"*"}}).contains(`"z"`, strongMatch) {
+		t.Error(`"*" did not match`)
+	}
+}
+
+func TestEtagListLenient(t *testing.T) {
+	list := etagList{values: []string{`abc, W/def`}, lenient: true}
+	if !list.valid() || !list.contains(`"abc"`, strongMatch) || !list.contains(`"def"`, weakMatch) {
+		t.Errorf("lenient list %q did not normalize its tags", list.values)
+	}
+}
+
+func TestEtagsAllocs(t *testing.T) {
+	r := httptest.NewRequest(Get, "/", nil)
+	r.Header.Set(IfNoneMatch, `W/"x", "y", "abc", W/"a,b"`)
+	r.Header.Set(IfMatch, `*`)
+	cfg := DefaultConfig
+
+	allocs := testing.AllocsPerRun(100, func() {
+		ifNoneMatch, _ := cfg.etags(r, IfNoneMatch, nil)
+		ifMatch, _ := cfg.etags(r, IfMatch, nil)
+		ifNoneMatch.contains(`"abc"`, weakMatch)
+		ifMatch.contains(`"abc"`, strongMatch)
+	})
+	if allocs != 0 {
+		t.Errorf("parsing allocated %v times, want 0", allocs)
+	}
+}
+
+func BenchmarkEtagsIfNoneMatch(b *testing.B) {
+	r := httptest.NewRequest(Get, "/", nil)
+	r.Header.Set(IfNoneMatch, `W/"x", "y", "z", W/"a,b", "abc"`)
+	cfg := DefaultConfig
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		list, _ := cfg.etags(r, IfNoneMatch, nil)
+		list.contains(`"abc"`, weakMatch)
+	}
+}
+
+func BenchmarkEtagsIfMatch(b *testing.B) {
+	r := httptest.NewRequest(Put, "/", nil)
+	r.Header.Set(IfMatch, `"abc"`)
+	cfg := DefaultConfig
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		list, _ := cfg.etags(r, IfMatch, nil)
+		list.contains(`"abc"`, strongMatch)
+	}
+}
+
+func TestEtagFromReader(t *testing.T) {
+	data := strings.Repeat("0123456789", 100)
+
+	tests := []struct {
+		name  string
+		limit int64
+		files int
+	}{
+		{"in memory", 0, 0},
+		{"spilled", 64, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			cfg := &Config{BufferLimit: tt.limit, SpillDir: dir}
+
+			etag, replay, err := cfg.EtagFromReader(strings.NewReader(data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := cfg.EtagFromBytes([]byte(data)); etag != want {
+				t.Errorf("ETag = %s, want %s", etag, want)
+			}
+			if files, _ := os.ReadDir(dir); len(files) != tt.files {
+				t.Errorf("%d spill files, want %d", len(files), tt.files)
+			}
+
+			got, err := io.ReadAll(replay)
+			if string(got) != data || err != nil {
+				t.Errorf("replayed %d bytes, %v, want the %d read", len(got), err, len(data))
+			}
+			if files, _ := os.ReadDir(dir); len(files) != 0 {
+				t.Errorf("%d spill files left after EOF", len(files))
+			}
+			if n, err := replay.Read(make([]byte, 1)); n != 0 || err != io.EOF {
+				t.Errorf("Read after EOF = %d, %v", n, err)
+			}
+		})
+	}
+}
+
+func TestEtagFromReaderClose(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &Config{BufferLimit: 4, SpillDir: dir}
+	_, replay, err := cfg.EtagFromReader(strings.NewReader("0123456789"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	replay.Read(make([]byte, 2))
+	if err := replay.(io.Closer).Close(); err != nil {
+		t.Fatal(err)
+	}
+	if files, _ := os.ReadDir(dir); len(files) != 0 {
+		t.Errorf("%d spill files left after Close", len(files))
+	}
+}
+
+func TestEtagFromReaderError(t *testing.T) {
+	dir := t.TempDir()
+	errRead := errors.New("read failed")
+	r := io.MultiReader(strings.NewReader("0123456789"), iotest.ErrReader(errRead))
+
+	_, replay, err := (&Config{BufferLimit: 4, SpillDir: dir}).EtagFromReader(r)
+	if !errors.Is(err, errRead) || replay != nil {
+		t.Errorf("got %v, %v, want the read error", replay, err)
+	}
+	if files, _ := os.ReadDir(dir); len(files) != 0 {
+		t.Errorf("%d spill files left after the error", len(files))
+	}
+}
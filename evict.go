@@ -0,0 +1,258 @@
+package conditional
+
+import "container/list"
+
+// EvictionPolicy decides which key a bounded in-memory store drops when it
+// is full. Policies are not safe for concurrent use, stores call them while
+// holding their own lock.
+//
+// Revalidation traffic often touches many keys once per poll interval,
+// which favours LFU or ARC over plain LRU for some workloads.
+type EvictionPolicy interface {
+	// Added records a key newly inserted into the store.
+	Added(key string)
+
+	// Accessed records a hit on a key already in the store.
+	Accessed(key string)
+
+	// Removed forgets a key the store dropped for another reason.
+	Removed(key string)
+
+	// Victim picks the key to evict and forgets it.
+	Victim() (string, bool)
+}
+
+// PolicyFactory makes the EvictionPolicy of a store, or of each shard of a
+// sharded store, holding at most capacity keys. NewARC is one, LRU and LFU
+// adapt the other built-in policies:
+//
+//	store := conditional.NewBoundedContentStore(1000, conditional.NewARC)
+type PolicyFactory func(capacity int) EvictionPolicy
+
+// LRU is the PolicyFactory of NewLRU.
+func LRU(capacity int) EvictionPolicy {
+	return NewLRU()
+}
+
+// LFU is the PolicyFactory of NewLFU.
+func LFU(capacity int) EvictionPolicy {
+	return NewLFU()
+}
+
+// lruPolicy evicts the least recently used key.
+type lruPolicy struct {
+	order *list.List
+	keys  map[string]*list.Element
+}
+
+func NewLRU() EvictionPolicy {
+	return &lruPolicy{order: list.New(), keys: make(map[string]*list.Element)}
+}
+
+func (p *lruPolicy) Added(key string) {
+	if e, ok := p.keys[key]; ok {
+		p.order.MoveToFront(e)
+		return
+	}
+	p.keys[key] = p.order.PushFront(key)
+}
+
+func (p *lruPolicy) Accessed(key string) {
+	if e, ok := p.keys[key]; ok {
+		p.order.MoveToFront(e)
+	}
+}
+
+func (p *lruPolicy) Removed(key string) {
+	if e, ok := p.keys[key]; ok {
+		p.order.Remove(e)
+		delete(p.keys, key)
+	}
+}
+
+func (p *lruPolicy) Victim() (string, bool) {
+	e := p.order.Back()
+	if e == nil {
+		return "", false
+	}
+	key := p.order.Remove(e).(string)
+	delete(p.keys, key)
+	return key, true
+}
+
+// lfuPolicy evicts the least frequently used key, breaking ties by
+// recency. Keys are bucketed by frequency so every operation is O(1).
+type lfuPolicy struct {
+	keys    map[string]*lfuEntry
+	buckets map[int]*list.List
+	minFreq int
+}
+
+type lfuEntry struct {
+	key  string
+	freq int
+	elem *list.Element
+}
+
+func NewLFU() EvictionPolicy {
+	return &lfuPolicy{keys: make(map[string]*lfuEntry), buckets: make(map[int]*list.List)}
+}
+
+func (p *lfuPolicy) bucket(freq int) *list.List {
+	l, ok := p.buckets[freq]
+	if !ok {
+		l = list.New()
+		p.buckets[freq] = l
+	}
+	return l
+}
+
+func (p *lfuPolicy) detach(entry *lfuEntry) {
+	l := p.buckets[entry.freq]
+	l.Remove(entry.elem)
+	if l.Len() == 0 {
+		delete(p.buckets, entry.freq)
+	}
+}
+
+func (p *lfuPolicy) Added(key string) {
+	if _, ok := p.keys[key]; ok {
+		p.Accessed(key)
+		return
+	}
+	entry := &lfuEntry{key: key, freq: 1}
+	entry.elem = p.bucket(1).PushFront(entry)
+	p.keys[key] = entry
+	p.minFreq = 1
+}
+
+func (p *lfuPolicy) Accessed(key string) {
+	entry, ok := p.keys[key]
+	if !ok {
+		return
+	}
+	p.detach(entry)
+	if entry.freq == p.minFreq && p.buckets[entry.freq] == nil {
+		p.minFreq++
+	}
+	entry.freq++
+	entry.elem = p.bucket(entry.freq).PushFront(entry)
+}
+
+func (p *lfuPolicy) Removed(key string) {
+	if entry, ok := p.keys[key]; ok {
+		p.detach(entry)
+		delete(p.keys, key)
+	}
+}
+
+func (p *lfuPolicy) Victim() (string, bool) {
+	if len(p.keys) == 0 {
+		return "", false
+	}
+
+	// Explicit removals can leave minFreq pointing at an empty bucket.
+	for p.buckets[p.minFreq] == nil {
+		p.minFreq++
+	}
+
+	l := p.buckets[p.minFreq]
+	entry := l.Back().Value.(*lfuEntry)
+	p.detach(entry)
+	delete(p.keys, entry.key)
+	return entry.key, true
+}
+
+// arcPolicy implements Adaptive Replacement Cache, balancing recency (t1)
+// against frequency (t2) using ghost lists of recently evicted keys
+// (b1, b2) to tune the target size p of t1.
+type arcPolicy struct {
+	capacity       int
+	p              int
+	t1, t2, b1, b2 *list.List
+	keys           map[string]*arcEntry
+}
+
+type arcEntry struct {
+	key  string
+	list *list.List
+	elem *list.Element
+}
+
+func NewARC(capacity int) EvictionPolicy {
+	return &arcPolicy{
+		capacity: capacity,
+		t1:       list.New(),
+		t2:       list.New(),
+		b1:       list.New(),
+		b2:       list.New(),
+		keys:     make(map[string]*arcEntry),
+	}
+}
+
+func (p *arcPolicy) move(entry *arcEntry, to *list.List) {
+	if entry.list != nil {
+		entry.list.Remove(entry.elem)
+	}
+	entry.list = to
+	entry.elem = to.PushFront(entry)
+}
+
+func (p *arcPolicy) drop(l *list.List) {
+	if e := l.Back(); e != nil {
+		entry := l.Remove(e).(*arcEntry)
+		delete(p.keys, entry.key)
+	}
+}
+
+func (p *arcPolicy) Added(key string) {
+	entry, ok := p.keys[key]
+	if !ok {
+		// Complete miss, keep the ghost lists within the directory size.
+		if p.t1.Len()+p.b1.Len() >= p.capacity {
+			p.drop(p.b1)
+		} else if p.t1.Len()+p.t2.Len()+p.b1.Len()+p.b2.Len() >= 2*p.capacity {
+			p.drop(p.b2)
+		}
+		entry = &arcEntry{key: key}
+		p.keys[key] = entry
+		p.move(entry, p.t1)
+		return
+	}
+
+	switch entry.list {
+	case p.b1:
+		p.p = min(p.capacity, p.p+max(p.b2.Len()/max(p.b1.Len(), 1), 1))
+	case p.b2:
+		p.p = max(0, p.p-max(p.b1.Len()/max(p.b2.Len(), 1), 1))
+	}
+	p.move(entry, p.t2)
+}
+
+func (p *arcPolicy) Accessed(key string) {
+	if entry, ok := p.keys[key]; ok && (entry.list == p.t1 || entry.list == p.t2) {
+		p.move(entry, p.t2)
+	}
+}
+
+func (p *arcPolicy) Removed(key string) {
+	if entry, ok := p.keys[key]; ok {
+		entry.list.Remove(entry.elem)
+		delete(p.keys, key)
+	}
+}
+
+func (p *arcPolicy) Victim() (string, bool) {
+	from, ghost := p.t2, p.b2
+	if p.t1.Len() > 0 && (p.t1.Len() > p.p || p.t2.Len() == 0) {
+		from, ghost = p.t1, p.b1
+	}
+
+	e := from.Back()
+	if e == nil {
+		return "", false
+	}
+	entry := e.Value.(*arcEntry)
+	p.move(entry, ghost)
+	return entry.key, true
+}
@@ -0,0 +1,136 @@
+package conditional
+
+import (
+	"math/rand"
+	"strconv"
+	"testing"
+)
+
+func victims(t *testing.T, p EvictionPolicy, want ...string) {
+	t.Helper()
+	for _, key := range want {
+		if got, ok := p.Victim(); !ok || got != key {
+			t.Fatalf("victim = %q, %v, want %q", got, ok, key)
+		}
+	}
+}
+
+func TestLRUEvictsLeastRecent(t *testing.T) {
+	p := NewLRU()
+	p.Added("a")
+	p.Added("b")
+	p.Added("c")
+	p.Accessed("a")
+	victims(t, p, "b", "c", "a")
+	if _, ok := p.Victim(); ok {
+		t.Error("empty policy returned a victim")
+	}
+}
+
+func TestLRURemoved(t *testing.T) {
+	p := NewLRU()
+	p.Added("a")
+	p.Added("b")
+	p.Removed("a")
+	victims(t, p, "b")
+}
+
+func TestLFUEvictsLeastFrequent(t *testing.T) {
+	p := NewLFU()
+	p.Added("a")
+	p.Added("b")
+	p.Added("c")
+	p.Accessed("a")
+	p.Accessed("a")
+	p.Accessed("b")
+	victims(t, p, "c", "b", "a")
+}
+
+func TestLFUBreaksTiesByRecency(t *testing.T) {
+	p := NewLFU()
+	p.Added("a")
+	p.Added("b")
+	p.Accessed("a")
+	p.Accessed("b")
+	victims(t, p, "a", "b")
+}
+
+func TestLFURemovedMinimum(t *testing.T) {
+	p := NewLFU()
+	p.Added("a")
+	p.Added("b")
+	p.Accessed("b")
+	p.Removed("a")
+	victims(t, p, "b")
+}
+
+func TestARCKeepsFrequentKeys(t *testing.T) {
+	p := NewARC(2)
+	p.Added("a")
+	p.Added("b")
+	p.Accessed("a")
+
+	// b was seen once, a twice.
+	victims(t, p, "b")
+
+	// A hit in the ghost list of b favours recency and brings b back as
+	// frequent, a is now the oldest frequent key.
+	p.Added("b")
+	victims(t, p, "a")
+}
+
+func TestARCScanResistance(t *testing.T) {
+	p := NewARC(4)
+	for _, key := range []string{"hot1", "hot2"} {
+		p.Added(key)
+		p.Accessed(key)
+	}
+	// Keys read once each must not push out the frequent ones.
+	for i := 0; i < 8; i++ {
+		key := "scan" + strconv.Itoa(i)
+		p.Added(key)
+		if i >= 1 {
+			if victim, _ := p.Victim(); victim == "hot1" || victim == "hot2" {
+				t.Fatalf("scan evicted %q", victim)
+			}
+		}
+	}
+}
+
+// Drives policy as a store of capacity keys would, over a skewed key
+// distribution like revalidation traffic.
+func benchmarkPolicy(b *testing.B, policy EvictionPolicy, capacity int) {
+	r := rand.New(rand.NewSource(1))
+	zipf := rand.NewZipf(r, 1.1, 1, uint64(capacity*10))
+	keys := make([]string, 1<<16)
+	for i := range keys {
+		keys[i] = strconv.FormatUint(zipf.Uint64(), 10)
+	}
+
+	present := make(map[string]bool, capacity)
+	hits := 0
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		key := keys[i&(len(keys)-1)]
+		if present[key] {
+			policy.Accessed(key)
+			hits++
+			continue
+		}
+		if len(present) >= capacity {
+			victim, _ := policy.Victim()
+			delete(present, victim)
+		}
+		policy.Added(key)
+		present[key] = true
+	}
+	b.ReportMetric(float64(hits)/float64(b.N), "hits/op")
+}
+
+func BenchmarkEvictionPolicy(b *testing.B) {
+	const capacity = 1000
+	b.Run("LRU", func(b *testing.B) { benchmarkPolicy(b, NewLRU(), capacity) })
+	b.Run("LFU", func(b *testing.B) { benchmarkPolicy(b, NewLFU(), capacity) })
+	b.Run("ARC", func(b *testing.B) { benchmarkPolicy(b, NewARC(capacity), capacity) })
+}
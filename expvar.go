@@ -0,0 +1,123 @@
+package conditional
+
+import (
+	"expvar"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ExpvarObserver counts evaluations in an expvar.Map, for services that
+// scrape /debug/vars rather than run a metrics system:
+//
+//	cfg.Observers = append(cfg.Observers, conditional.PublishExpvar("conditional"))
+//
+// The map holds "evaluations", "not_modified", "precondition_failed" and
+// "malformed" counters, and the "bytes_saved" by 304 and 206 responses.
+// Evaluations count as malformed whether the header was ignored or
+// rejected. With TimeValidators, "validator_time" holds a histogram of
+// Evaluation.ValidatorTime per route.
+type ExpvarObserver struct {
+	vars *expvar.Map
+
+	mu     sync.Mutex
+	routes *expvar.Map
+}
+
+// PublishExpvar publishes an ExpvarObserver's counters under name. Like
+// expvar.NewMap, it panics when name is already published.
+func PublishExpvar(name string) *ExpvarObserver {
+	o := &ExpvarObserver{vars: expvar.NewMap(name), routes: new(expvar.Map)}
+	o.vars.Set("validator_time", o.routes)
+	return o
+}
+
+func (o *ExpvarObserver) Observe(c *gin.Context, e Evaluation) {
+	o.vars.Add("evaluations", 1)
+	switch {
+	case e.Status == http.StatusNotModified:
+		o.vars.Add("not_modified", 1)
+	case e.Status == http.StatusPreconditionFailed:
+		o.vars.Add("precondition_failed", 1)
+	}
+	if len(e.Malformed) > 0 {
+		o.vars.Add("malformed", 1)
+	}
+
+	if e.ValidatorTime > 0 {
+		o.histogram(c.FullPath()).observe(e.ValidatorTime)
+	}
+}
+
+func (o *ExpvarObserver) BytesSaved(c *gin.Context, n int64) {
+	o.vars.Add("bytes_saved", n)
+}
+
+func (o *ExpvarObserver) histogram(route string) *histogram {
+	if h, ok := o.routes.Get(route).(*histogram); ok {
+		return h
+	}
+
+	// Two evaluations may race to add the route, only one is kept.
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if h, ok := o.routes.Get(route).(*histogram); ok {
+		return h
+	}
+	h := &histogram{buckets: make([]uint64, len(histogramBuckets)+1)}
+	o.routes.Set(route, h)
+	return h
+}
+
+// Upper bounds of the histogram buckets, the last one is unbounded.
+var histogramBuckets = []time.Duration{
+	time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+}
+
+// An expvar.Var counting durations in histogramBuckets.
+type histogram struct {
+	mu      sync.Mutex
+	count   uint64
+	sum     time.Duration
+	buckets []uint64
+}
+
+func (h *histogram) observe(d time.Duration) {
+	i := 0
+	for i < len(histogramBuckets) && d > histogramBuckets[i] {
+		i++
+	}
+
+	h.mu.Lock()
+	h.count++
+	h.sum += d
+	h.buckets[i]++
+	h.mu.Unlock()
+}
+
+// String encodes the histogram as JSON, with cumulative bucket counts
+// keyed by their upper bound in seconds, like Prometheus.
+func (h *histogram) String() string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `{"count": %d, "sum": %g, "buckets": {`, h.count, h.sum.Seconds())
+	var cumulative uint64
+	for i, bound := range histogramBuckets {
+		cumulative += h.buckets[i]
+		fmt.Fprintf(&b, `"%g": %d, `, bound.Seconds(), cumulative)
+	}
+	fmt.Fprintf(&b, `"+Inf": %d}}`, h.count)
+	return b.String()
+}
@@ -0,0 +1,43 @@
+package conditional
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestExpvarCountsMalformed(t *testing.T) {
+	for i, policy := range []MalformedPolicy{IgnoreMalformed, LogMalformed, RejectMalformed} {
+		o := PublishExpvar("conditional_malformed_" + string(rune('a'+i)))
+		cfg := &Config{Malformed: policy, Observers: []Observer{o}}
+		if policy == LogMalformed {
+			cfg.ErrorLog = log.New(io.Discard, "", 0)
+		}
+
+		for _, header := range []http.Header{
+			{IfNoneMatch: {`"unterminated`}},
+			{IfModifiedSince: {"yesterday"}},
+			{IfNoneMatch: {`"a"`}},
+		} {
+			gin.SetMode(gin.TestMode)
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(Get, "/", nil)
+			c.Request.Header = header
+			cfg.Conditional(c, BytesResource(nil, `"a"`, time.Unix(1e9, 0)))
+		}
+
+		var vars map[string]interface{}
+		if err := json.Unmarshal([]byte(o.vars.String()), &vars); err != nil {
+			t.Fatal(err)
+		}
+		if vars["malformed"] != 2.0 || vars["evaluations"] != 3.0 {
+			t.Errorf("policy %v: %s, want 2 malformed of 3 evaluations", policy, o.vars.String())
+		}
+	}
+}
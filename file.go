@@ -0,0 +1,61 @@
+package conditional
+
+import (
+	"errors"
+	"io/fs"
+	"net/http"
+	"os"
+
+	"github.com/gin-gonic/gin"
+)
+
+// File is c.File with the request's preconditions evaluated first against
+// the file's metadata, so downloads get ETag, Last-Modified, 304 and 412.
+func File(c *gin.Context, filepath string) {
+	ConfigOf(c).File(c, filepath)
+}
+
+func (cfg *Config) File(c *gin.Context, filepath string) {
+	if cfg.fileConditional(c, filepath) {
+		c.File(filepath)
+	}
+}
+
+// FileAttachment is c.FileAttachment with the request's preconditions
+// evaluated first, as in File.
+func FileAttachment(c *gin.Context, filepath, filename string) {
+	ConfigOf(c).FileAttachment(c, filepath, filename)
+}
+
+func (cfg *Config) FileAttachment(c *gin.Context, filepath, filename string) {
+	if cfg.fileConditional(c, filepath) {
+		c.FileAttachment(filepath, filename)
+	}
+}
+
+// Evaluates the preconditions against the file, reporting whether the
+// request should proceed to serve it.
+func (cfg *Config) fileConditional(c *gin.Context, filepath string) bool {
+	var resource interface{}
+	fi, err := os.Stat(filepath)
+	switch {
+	case err == nil && fi.IsDir():
+		// gin lists directories, which have no validators.
+		resource = contentOnly{}
+	case err == nil:
+		resource = FileInfoResource(fi)
+	case !errors.Is(err, fs.ErrNotExist):
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return false
+	}
+
+	handled, err := cfg.Conditional(c, resource)
+	if handled {
+		return false
+	}
+	if status := errorStatus(err); status != 0 {
+		c.AbortWithStatus(status)
+		return false
+	}
+	return true
+}
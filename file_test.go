@@ -0,0 +1,63 @@
+package conditional
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestFile(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	dir := t.TempDir()
+	name := filepath.Join(dir, "report.csv")
+	if err := os.WriteFile(name, []byte("a,b\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	modTime := time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+	if err := os.Chtimes(name, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+	etag := EtagFromFileInfo(must(os.Stat(name)))
+
+	r := gin.New()
+	r.GET("/file", func(c *gin.Context) { File(c, name) })
+	r.PUT("/file", func(c *gin.Context) { File(c, name) })
+	r.GET("/missing", func(c *gin.Context) { File(c, filepath.Join(dir, "missing")) })
+	r.GET("/attachment", func(c *gin.Context) { FileAttachment(c, name, "export.csv") })
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		header map[string]string
+		status int
+	}{
+		{"file", Get, "/file", nil, http.StatusOK},
+		{"etag match", Get, "/file", map[string]string{IfNoneMatch: etag}, http.StatusNotModified},
+		{"not modified since", Get, "/file", map[string]string{IfModifiedSince: modTime.Format(http.TimeFormat)}, http.StatusNotModified},
+		{"modified since", Get, "/file", map[string]string{IfModifiedSince: modTime.Add(-time.Hour).Format(http.TimeFormat)}, http.StatusOK},
+		{"if-match mismatch", Put, "/file", map[string]string{IfMatch: `"other"`}, http.StatusPreconditionFailed},
+		{"missing", Get, "/missing", nil, http.StatusNotFound},
+		{"missing if-match", Get, "/missing", map[string]string{IfMatch: "*"}, http.StatusPreconditionFailed},
+		{"attachment etag match", Get, "/attachment", map[string]string{IfNoneMatch: etag}, http.StatusNotModified},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := request(r, tt.method, tt.path, tt.header); w.Code != tt.status {
+				t.Errorf("got %d, want %d", w.Code, tt.status)
+			}
+		})
+	}
+
+	w := request(r, Get, "/attachment", nil)
+	if w.Code != http.StatusOK || w.Body.String() != "a,b\n" || w.Header().Get(ETag) != etag {
+		t.Errorf("attachment: got %d %q, ETag %q", w.Code, w.Body, w.Header().Get(ETag))
+	}
+	if got := w.Header().Get("Content-Disposition"); got != `attachment; filename="export.csv"` {
+		t.Errorf("Content-Disposition = %q", got)
+	}
+}
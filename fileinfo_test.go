@@ -0,0 +1,42 @@
+package conditional
+
+import (
+	"io/fs"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestEtagFromFileInfo(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a":     {Data: make([]byte, 1234), ModTime: time.Unix(1700000000, 0)},
+		"empty": {ModTime: time.Unix(1700000000, 0)},
+		"epoch": {Data: []byte("x"), ModTime: time.Unix(0, 0)},
+	}
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		// nginx: printf '"%x-%x"' mtime size.
+		{"a", `"6553f100-4d2"`},
+		{"empty", `"6553f100-0"`},
+		{"epoch", `"0-1"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fi := must(fs.Stat(fsys, tt.name))
+			if got := EtagFromFileInfo(fi); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+
+			resource := FileInfoResource(fi)
+			if etag, err := resource.(Etagger).Etag(); etag != tt.want || err != nil {
+				t.Errorf("resource ETag = %s, %v", etag, err)
+			}
+			if lm := resource.(LastModifier).LastModified(); !lm.Equal(fi.ModTime()) {
+				t.Errorf("resource LastModified = %v, want %v", lm, fi.ModTime())
+			}
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package conditional
+
+import (
+	"io/fs"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// FileSystem wraps an http.FileSystem, serving it as an http.Handler with
+// the semantics of StaticFS: validators, 304, 412 and Range. It is still
+// an http.FileSystem, so it fits wherever the wrapped one did:
+//
+//	router.GET("/static/*filepath", gin.WrapH(
+//		http.StripPrefix("/static", conditional.FileServer(http.FS(assets)))))
+type FileSystem struct {
+	http.FileSystem
+	handler http.Handler
+}
+
+// FileServer returns a FileSystem serving fsys using DefaultConfig.
+func FileServer(fsys http.FileSystem, opts ...Option) *FileSystem {
+	return DefaultConfig.FileServer(fsys, opts...)
+}
+
+func (cfg *Config) FileServer(fsys http.FileSystem, opts ...Option) *FileSystem {
+	engine := gin.New()
+	engine.Use(cfg.StaticFS("/", httpFS{fsys}, opts...))
+	return &FileSystem{FileSystem: fsys, handler: engine}
+}
+
+func (f *FileSystem) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.handler.ServeHTTP(w, r)
+}
+
+// httpFS adapts an http.FileSystem, whose files already implement
+// fs.File, to fs.FS.
+type httpFS struct {
+	fsys http.FileSystem
+}
+
+func (h httpFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	return h.fsys.Open("/" + name)
+}
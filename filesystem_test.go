@@ -0,0 +1,44 @@
+package conditional
+
+import (
+	"io/fs"
+	"net/http"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestFileServer(t *testing.T) {
+	fsys := fstest.MapFS{"css/site.css": {Data: []byte("body{}"), ModTime: time.Unix(1700000000, 0)}}
+	etag := EtagFromFileInfo(must(fs.Stat(fsys, "css/site.css")))
+	files := FileServer(http.FS(fsys))
+	h := http.StripPrefix("/static", files)
+
+	tests := []struct {
+		name   string
+		path   string
+		header map[string]string
+		status int
+		body   string
+	}{
+		{"file", "/static/css/site.css", nil, http.StatusOK, "body{}"},
+		{"etag match", "/static/css/site.css", map[string]string{IfNoneMatch: etag}, http.StatusNotModified, ""},
+		{"range", "/static/css/site.css", map[string]string{Range: "bytes=0-3"}, http.StatusPartialContent, "body"},
+		{"missing", "/static/missing.css", nil, http.StatusNotFound, "404 page not found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := request(h, Get, tt.path, tt.header)
+			if w.Code != tt.status || w.Body.String() != tt.body {
+				t.Errorf("got %d %q, want %d %q", w.Code, w.Body, tt.status, tt.body)
+			}
+		})
+	}
+
+	// Still usable as the http.FileSystem it wraps.
+	f, err := files.Open("/css/site.css")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+}
@@ -0,0 +1,57 @@
+package conditional
+
+import (
+	"io"
+	"regexp"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DefaultFingerprint matches file names carrying a hex content hash, such
+// as app.3f9c2d.js.
+var DefaultFingerprint = regexp.MustCompile(`\.[0-9a-f]{6,}\.[^./]+$`)
+
+// Freshness of fingerprinted assets: a year, never revalidated.
+var immutableCacheControl = CacheControl{Public: true, MaxAge: 365 * 24 * time.Hour, Immutable: true}
+
+// Fingerprinted serves paths matching pattern, DefaultFingerprint when
+// nil, as immutable: a changed file gets a new name, so they are cached
+// for a year and a conditional request is answered 304 without computing
+// validators. Other paths keep ETag revalidation.
+func Fingerprinted(pattern *regexp.Regexp) Option {
+	if pattern == nil {
+		pattern = DefaultFingerprint
+	}
+	return func(s *fileServer) {
+		s.fingerprint = pattern
+	}
+}
+
+func (s *fileServer) fingerprinted(name string) bool {
+	return s.fingerprint != nil && s.fingerprint.MatchString(name)
+}
+
+func (s *fileServer) serveImmutable(c *gin.Context, file *staticFile) {
+	immutableCacheControl.Apply(c)
+
+	// Any copy the client holds of this name is the current one.
+	if s.cfg.header(c.Request, IfNoneMatch) != "" || s.cfg.header(c.Request, IfModifiedSince) != "" {
+		s.cfg.NotModified(c, nil)
+		return
+	}
+	s.serve(c, file.name, immutableFile{file})
+}
+
+// immutableFile serves a file without validators.
+type immutableFile struct {
+	file *staticFile
+}
+
+func (f immutableFile) Exists() bool {
+	return true
+}
+
+func (f immutableFile) Content() (io.ReadSeeker, int64, error) {
+	return f.file.Content()
+}
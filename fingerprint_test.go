@@ -0,0 +1,52 @@
+package conditional
+
+import (
+	"net/http"
+	"regexp"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestFingerprinted(t *testing.T) {
+	modTime := time.Unix(1700000000, 0)
+	fsys := fstest.MapFS{
+		"app.3f9c2d.js": {Data: []byte("app"), ModTime: modTime},
+		"app.js":        {Data: []byte("app"), ModTime: modTime},
+		"v2/logo.png":   {Data: []byte("png"), ModTime: modTime},
+	}
+
+	tests := []struct {
+		name      string
+		pattern   *regexp.Regexp
+		path      string
+		header    map[string]string
+		status    int
+		immutable bool
+	}{
+		{"fingerprinted", nil, "/assets/app.3f9c2d.js", nil, http.StatusOK, true},
+		{"any etag", nil, "/assets/app.3f9c2d.js", map[string]string{IfNoneMatch: `"anything"`}, http.StatusNotModified, true},
+		{"any date", nil, "/assets/app.3f9c2d.js", map[string]string{IfModifiedSince: modTime.Add(-time.Hour).Format(http.TimeFormat)}, http.StatusNotModified, true},
+		{"plain", nil, "/assets/app.js", nil, http.StatusOK, false},
+		{"plain etag mismatch", nil, "/assets/app.js", map[string]string{IfNoneMatch: `"anything"`}, http.StatusOK, false},
+		{"custom pattern", regexp.MustCompile(`^v\d+/`), "/assets/v2/logo.png", nil, http.StatusOK, true},
+		{"outside custom pattern", regexp.MustCompile(`^v\d+/`), "/assets/app.3f9c2d.js", nil, http.StatusOK, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := staticRouter(&Config{}, fsys, Fingerprinted(tt.pattern))
+			w := request(r, Get, tt.path, tt.header)
+			if w.Code != tt.status {
+				t.Errorf("got %d, want %d", w.Code, tt.status)
+			}
+
+			cacheControl := w.Header().Get("Cache-Control")
+			if immutable := cacheControl == "public, max-age=31536000, immutable"; immutable != tt.immutable {
+				t.Errorf("Cache-Control = %q, immutable %v, want %v", cacheControl, immutable, tt.immutable)
+			}
+			if etag := w.Header().Get(ETag); tt.immutable == (etag != "") {
+				t.Errorf("ETag = %q, immutable %v", etag, tt.immutable)
+			}
+		})
+	}
+}
@@ -0,0 +1,173 @@
+package conditional
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// The gin.Context key a GuardBuilder stores the loaded resource under.
+const ResourceKey = "conditional.resource"
+
+// Loader fetches the resource identified by key. It returns a nil resource
+// or ErrNoResource when nothing exists there.
+type Loader func(c *gin.Context, key string) (interface{}, error)
+
+// CachePolicy applies freshness headers to a response.
+type CachePolicy interface {
+	Apply(c *gin.Context)
+}
+
+// GuardBuilder registers the routes of one resource with the conditional
+// semantics each method needs, so endpoints don't diverge:
+//
+//	conditional.Build().
+//		Load(loader).
+//		CachePolicy(policy).
+//		Handlers(get, put, delete).
+//		Register(router, "/articles/:id")
+//
+// Handlers find the loaded resource with Resource.
+type GuardBuilder struct {
+	cfg    *Config
+	load   Loader
+	policy CachePolicy
+	key    KeyFunc
+	on404  gin.HandlerFunc
+
+	get, put, patch, delete gin.HandlerFunc
+}
+
+// Build starts a GuardBuilder using DefaultConfig.
+func Build() *GuardBuilder {
+	return DefaultConfig.Build()
+}
+
+func (cfg *Config) Build() *GuardBuilder {
+	return &GuardBuilder{cfg: cfg}
+}
+
+func (b *GuardBuilder) Load(loader Loader) *GuardBuilder {
+	b.load = loader
+	return b
+}
+
+// CachePolicy is applied to GET and HEAD responses, including 304s.
+func (b *GuardBuilder) CachePolicy(policy CachePolicy) *GuardBuilder {
+	b.policy = policy
+	return b
+}
+
+// Keys overrides the Config's KeyFunc for this resource.
+func (b *GuardBuilder) Keys(key KeyFunc) *GuardBuilder {
+	b.key = key
+	return b
+}
+
+// On404 replaces the bare 404 sent when the resource does not exist.
+func (b *GuardBuilder) On404(handler gin.HandlerFunc) *GuardBuilder {
+	b.on404 = handler
+	return b
+}
+
+// Handlers sets the GET, PUT and DELETE handlers, any of which may be nil.
+// GET also serves HEAD.
+func (b *GuardBuilder) Handlers(get, put, delete gin.HandlerFunc) *GuardBuilder {
+	b.get, b.put, b.delete = get, put, delete
+	return b
+}
+
+func (b *GuardBuilder) Patch(handler gin.HandlerFunc) *GuardBuilder {
+	b.patch = handler
+	return b
+}
+
+// Register adds a route to r for every method with a handler.
+func (b *GuardBuilder) Register(r gin.IRoutes, path string) {
+	if b.get != nil {
+		r.GET(path, b.guard(b.get, false))
+		r.HEAD(path, b.guard(b.get, false))
+	}
+	if b.put != nil {
+		r.PUT(path, b.guard(b.put, true))
+	}
+	if b.patch != nil {
+		r.PATCH(path, b.guard(b.patch, false))
+	}
+	if b.delete != nil {
+		r.DELETE(path, b.guard(b.delete, false))
+	}
+}
+
+// Resource returns the resource loaded by a GuardBuilder, nil when the
+// request creates it.
+func Resource(c *gin.Context) interface{} {
+	resource, _ := c.Get(ResourceKey)
+	return resource
+}
+
+// Wraps handler with loading and precondition evaluation. Only methods
+// that may create the resource proceed when it is absent.
+func (b *GuardBuilder) guard(handler gin.HandlerFunc, creates bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := ""
+		if b.key != nil {
+			key = b.key(c)
+		} else {
+			key = b.cfg.key(c)
+		}
+
+		var resource interface{}
+		if b.load != nil {
+			var err error
+			resource, err = b.load(c, key)
+			if err != nil && !errors.Is(err, ErrNoResource) {
+				c.AbortWithError(http.StatusInternalServerError, err)
+				return
+			}
+			if errors.Is(err, ErrNoResource) {
+				resource = nil
+			}
+		}
+
+		safe := c.Request.Method == Get || c.Request.Method == Head
+		if safe && b.policy != nil {
+			b.policy.Apply(c)
+		}
+
+		handled, err := b.cfg.Conditional(c, resource)
+		if handled {
+			return
+		}
+
+		if status := errorStatus(err); status == http.StatusNotFound {
+			b.notFound(c)
+			return
+		} else if status != 0 {
+			c.AbortWithStatus(status)
+			return
+		}
+
+		if resourceAbsent(resource, b.cfg.resolve(c, resource)) && !creates {
+			b.notFound(c)
+			return
+		}
+
+		c.Set(ResourceKey, resource)
+		handler(c)
+
+		if status := c.Writer.Status(); !safe && status >= 200 && status < 300 {
+			b.cfg.ResourceChanged(key)
+		}
+	}
+}
+
+func (b *GuardBuilder) notFound(c *gin.Context) {
+	if b.on404 != nil {
+		b.on404(c)
+		c.Abort()
+		return
+	}
+	c.AbortWithStatus(http.StatusNotFound)
+}
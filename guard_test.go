@@ -0,0 +1,94 @@
+package conditional
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestGuardBuilder(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	articles := map[string]*Bytes{"1": BytesResource([]byte("one"), `"v1"`, time.Time{})}
+	var changed []string
+	cfg := &Config{Invalidators: []Invalidator{InvalidatorFunc(func(key string) {
+		changed = append(changed, key)
+	})}}
+
+	r := gin.New()
+	cfg.Build().
+		Load(func(c *gin.Context, key string) (interface{}, error) {
+			if key == "broken" {
+				return nil, errors.New("database down")
+			}
+			if a, ok := articles[key]; ok {
+				return a, nil
+			}
+			return nil, ErrNoResource
+		}).
+		Keys(func(c *gin.Context) string { return c.Param("id") }).
+		CachePolicy(CacheControl{MaxAge: time.Minute}).
+		Handlers(
+			func(c *gin.Context) { c.String(http.StatusOK, string(Resource(c).(*Bytes).data)) },
+			func(c *gin.Context) {
+				created := Resource(c) == nil
+				articles[c.Param("id")] = BytesResource([]byte("new"), `"v2"`, time.Time{})
+				if created {
+					c.Status(http.StatusCreated)
+				} else {
+					c.Status(http.StatusNoContent)
+				}
+			},
+			func(c *gin.Context) {
+				delete(articles, c.Param("id"))
+				c.Status(http.StatusNoContent)
+			},
+		).
+		Patch(func(c *gin.Context) { c.Status(http.StatusConflict) }).
+		On404(func(c *gin.Context) { c.String(http.StatusNotFound, "no such article") }).
+		Register(r, "/articles/:id")
+
+	tests := []struct {
+		name    string
+		method  string
+		path    string
+		header  map[string]string
+		status  int
+		body    string
+		changed []string
+	}{
+		{"get", Get, "/articles/1", nil, http.StatusOK, "one", nil},
+		// The recorder keeps what net/http would drop from a HEAD response.
+		{"head", Head, "/articles/1", nil, http.StatusOK, "one", nil},
+		{"revalidated", Get, "/articles/1", map[string]string{IfNoneMatch: `"v1"`}, http.StatusNotModified, "", nil},
+		{"missing", Get, "/articles/2", nil, http.StatusNotFound, "no such article", nil},
+		{"loader error", Get, "/articles/broken", nil, http.StatusInternalServerError, "", nil},
+		{"lost update", Put, "/articles/1", map[string]string{IfMatch: `"v0"`}, http.StatusPreconditionFailed, "", nil},
+		{"patch absent", Patch, "/articles/2", nil, http.StatusNotFound, "no such article", nil},
+		{"failed patch", Patch, "/articles/1", nil, http.StatusConflict, "", nil},
+		{"create", Put, "/articles/2", map[string]string{IfNoneMatch: "*"}, http.StatusCreated, "", []string{"2"}},
+		{"create existing", Put, "/articles/2", map[string]string{IfNoneMatch: "*"}, http.StatusPreconditionFailed, "", nil},
+		{"update", Put, "/articles/1", map[string]string{IfMatch: `"v1"`}, http.StatusNoContent, "", []string{"1"}},
+		{"delete", Delete, "/articles/1", map[string]string{IfMatch: `"v2"`}, http.StatusNoContent, "", []string{"1"}},
+		{"delete absent", Delete, "/articles/1", nil, http.StatusNotFound, "no such article", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changed = nil
+			w := request(r, tt.method, tt.path, tt.header)
+			if w.Code != tt.status || w.Body.String() != tt.body {
+				t.Errorf("got %d %q, want %d %q", w.Code, w.Body, tt.status, tt.body)
+			}
+			if len(changed) != len(tt.changed) || len(changed) > 0 && changed[0] != tt.changed[0] {
+				t.Errorf("changed %q, want %q", changed, tt.changed)
+			}
+
+			safe := tt.method == Get || tt.method == Head
+			if cc := w.Header().Get("Cache-Control"); safe && tt.status < 400 && cc != "max-age=60" || !safe && cc != "" {
+				t.Errorf("Cache-Control = %q", cc)
+			}
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package conditional
+
+import "time"
+
+// LatestOf returns the latest of times, or the zero time when none is
+// given. Zero times are ignored.
+func LatestOf(times ...time.Time) time.Time {
+	var latest time.Time
+	for _, t := range times {
+		if t.After(latest) {
+			latest = t
+		}
+	}
+	return latest
+}
+
+// Composite returns a LastModifier for a response assembled from several
+// parts, such as an entity, its template and configuration. It was last
+// modified when its most recently modified part was.
+func Composite(parts ...LastModifier) LastModifier {
+	return composite(parts)
+}
+
+type composite []LastModifier
+
+func (c composite) LastModified() time.Time {
+	var latest time.Time
+	for _, part := range c {
+		latest = LatestOf(latest, part.LastModified())
+	}
+	return latest
+}
@@ -0,0 +1,63 @@
+package conditional
+
+import (
+	"testing"
+	"time"
+)
+
+type modifiedAt time.Time
+
+func (m modifiedAt) LastModified() time.Time {
+	return time.Time(m)
+}
+
+func TestLatestOf(t *testing.T) {
+	t1 := time.Date(2001, 9, 9, 1, 46, 40, 0, time.UTC)
+	t2 := t1.Add(time.Hour)
+	// The same instant in another zone.
+	t2East := t2.In(time.FixedZone("east", 3600))
+
+	tests := []struct {
+		name  string
+		times []time.Time
+		want  time.Time
+	}{
+		{"none", nil, time.Time{}},
+		{"only zero", []time.Time{{}, {}}, time.Time{}},
+		{"one", []time.Time{t1}, t1},
+		{"latest last", []time.Time{t1, t2}, t2},
+		{"latest first", []time.Time{t2, t1}, t2},
+		{"zero ignored", []time.Time{{}, t1, {}}, t1},
+		{"zones", []time.Time{t1, t2East}, t2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := LatestOf(tt.times...); !got.Equal(tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestComposite(t *testing.T) {
+	t1 := time.Date(2001, 9, 9, 1, 46, 40, 0, time.UTC)
+	t2 := t1.Add(time.Hour)
+
+	tests := []struct {
+		name  string
+		parts []LastModifier
+		want  time.Time
+	}{
+		{"no parts", nil, time.Time{}},
+		{"entity and template", []LastModifier{modifiedAt(t1), modifiedAt(t2)}, t2},
+		{"part without a date", []LastModifier{modifiedAt(time.Time{}), modifiedAt(t1)}, t1},
+		{"nested", []LastModifier{modifiedAt(t1), Composite(modifiedAt(t2))}, t2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Composite(tt.parts...).LastModified(); !got.Equal(tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package conditional
+
+import (
+	"sync"
+	"time"
+)
+
+// Lazy returns a resource whose validators are computed by compute on
+// first use only: when the request carries a precondition to evaluate, or
+// when the validators are set on the response, so with OmitValidators a
+// plain GET never calls it. compute runs at most once.
+//
+// Lazy resources are taken to exist; an ErrNoResource from compute is
+// reported through Etag like any other error.
+func Lazy(compute func() (Validators, error)) interface{} {
+	return &lazyResource{compute: compute}
+}
+
+type lazyResource struct {
+	compute func() (Validators, error)
+
+	once       sync.Once
+	validators Validators
+	err        error
+}
+
+func (l *lazyResource) get() (Validators, error) {
+	l.once.Do(func() {
+		l.validators, l.err = l.compute()
+	})
+	return l.validators, l.err
+}
+
+func (l *lazyResource) Exists() bool {
+	return true
+}
+
+func (l *lazyResource) Etag() (string, error) {
+	v, err := l.get()
+	return v.ETag, err
+}
+
+func (l *lazyResource) LastModified() time.Time {
+	v, _ := l.get()
+	return v.LastModified
+}
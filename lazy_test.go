@@ -0,0 +1,64 @@
+package conditional
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestLazy(t *testing.T) {
+	modified := time.Date(2001, 9, 9, 1, 46, 40, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		cfg     *Config
+		method  string
+		header  map[string]string
+		calls   int
+		status  int
+		handled bool
+		err     error
+	}{
+		{"plain get omitting validators", &Config{OmitValidators: true}, Get, nil, 0, http.StatusOK, false, nil},
+		{"plain get", &Config{}, Get, nil, 1, http.StatusOK, false, nil},
+		{"revalidation", &Config{}, Get, map[string]string{IfNoneMatch: `"a"`}, 1, http.StatusNotModified, true, nil},
+		{"by date", &Config{}, Get, map[string]string{IfModifiedSince: modified.Format(http.TimeFormat)}, 1, http.StatusNotModified, true, nil},
+		{"both validators", &Config{}, Get, map[string]string{IfNoneMatch: `"b"`, IfModifiedSince: modified.Format(http.TimeFormat)}, 1, http.StatusOK, false, nil},
+		// Left to the handler, which may find the write already applied.
+		{"lost update", &Config{}, Put, map[string]string{IfMatch: `"b"`}, 1, http.StatusOK, false, ErrWasModified},
+		{"unconditional write", &Config{}, Put, nil, 0, http.StatusOK, false, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			resource := Lazy(func() (Validators, error) {
+				calls++
+				return Validators{ETag: `"a"`, LastModified: modified}, nil
+			})
+
+			w, handled, err := runConditional(tt.cfg, tt.method, tt.header, resource)
+			if !errors.Is(err, tt.err) || handled != tt.handled || w.Code != tt.status {
+				t.Errorf("got %d, handled %v, %v, want %d, handled %v", w.Code, handled, err, tt.status, tt.handled)
+			}
+			if calls != tt.calls {
+				t.Errorf("compute called %d times, want %d", calls, tt.calls)
+			}
+		})
+	}
+}
+
+func TestLazyErrors(t *testing.T) {
+	for _, computeErr := range []error{errBackend, ErrNoResource} {
+		resource := Lazy(func() (Validators, error) {
+			return Validators{}, computeErr
+		})
+
+		// Lazy resources exist, so even a missing one is an error rather
+		// than a 412 or 404.
+		_, handled, err := runConditional(&Config{}, Put, map[string]string{IfMatch: "*"}, resource)
+		if handled || !errors.Is(err, computeErr) || !errors.Is(err, ErrValidatorFailed) {
+			t.Errorf("%v: handled %v, %v, want the compute error", computeErr, handled, err)
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package conditional
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Runs the lifecycle callback for e's decision, before a 304 or 412 is
+// written and before a request proceeds, so the callback may still add
+// headers.
+func (cfg *Config) callback(c *gin.Context, e Evaluation) {
+	var fn func(*gin.Context, Evaluation)
+	switch e.Status {
+	case http.StatusNotModified:
+		fn = cfg.OnNotModified
+	case http.StatusPreconditionFailed:
+		fn = cfg.OnPreconditionFailed
+	case 0:
+		fn = cfg.OnServed
+	}
+	if fn != nil {
+		fn(c, e)
+	}
+}
@@ -0,0 +1,97 @@
+package conditional
+
+import (
+	"bytes"
+	"html"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Listings makes StaticFS list directories without an index.html. A
+// listing's ETag is derived from its entries, so it revalidates as files
+// are added, removed or changed.
+func Listings() Option {
+	return func(s *fileServer) {
+		s.listings = true
+	}
+}
+
+// EtagFromDirEntries returns a strong ETag for a set of directory
+// entries, from their names, sizes and modification times.
+func EtagFromDirEntries(entries []fs.DirEntry) (string, error) {
+	return DefaultConfig.EtagFromDirEntries(entries)
+}
+
+func (cfg *Config) EtagFromDirEntries(entries []fs.DirEntry) (string, error) {
+	h := cfg.newHash()
+	for _, entry := range entries {
+		fi, err := entry.Info()
+		if err != nil {
+			return "", err
+		}
+		h.Write([]byte(entry.Name()))
+		h.Write([]byte{0})
+		h.Write([]byte(strconv.FormatInt(fi.Size(), 10)))
+		h.Write([]byte{0})
+		h.Write([]byte(strconv.FormatInt(fi.ModTime().UnixNano(), 10)))
+		h.Write([]byte{'\n'})
+	}
+	return cfg.etagFromSum(h.Sum(nil)), nil
+}
+
+// Renders the listing of the directory name, reporting false when it is
+// not a directory.
+func (s *fileServer) listing(c *gin.Context, name string) (*Bytes, bool, error) {
+	fi, err := fs.Stat(s.fsys, name)
+	if err != nil || !fi.IsDir() {
+		return nil, false, nil
+	}
+	entries, err := fs.ReadDir(s.fsys, name)
+	if err != nil {
+		return nil, true, err
+	}
+	etag, err := s.cfg.EtagFromDirEntries(entries)
+	if err != nil {
+		return nil, true, err
+	}
+
+	base := strings.TrimSuffix(c.Request.URL.Path, "/")
+	modified := []time.Time{fi.ModTime()}
+	var buf bytes.Buffer
+	buf.WriteString("<!doctype html>\n<meta name=\"viewport\" content=\"width=device-width\">\n<pre>\n")
+	for _, entry := range entries {
+		entryName := entry.Name()
+		if entry.IsDir() {
+			entryName += "/"
+		}
+		if info, err := entry.Info(); err == nil {
+			modified = append(modified, info.ModTime())
+		}
+		href := (&url.URL{Path: base + "/" + entryName}).EscapedPath()
+		buf.WriteString(`<a href="` + html.EscapeString(href) + `">` + html.EscapeString(entryName) + "</a>\n")
+	}
+	buf.WriteString("</pre>\n")
+
+	return &Bytes{data: buf.Bytes(), etag: etag, lastModified: LatestOf(modified...)}, true, nil
+}
+
+// Serves the listing of name, reporting false when it is not a directory.
+func (s *fileServer) serveListing(c *gin.Context, name string) bool {
+	listing, ok, err := s.listing(c, name)
+	if !ok {
+		return false
+	}
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return true
+	}
+	c.Writer.Header().Set("Content-Type", "text/html; charset=utf-8")
+	s.cfg.Serve(c, listing)
+	return true
+}
@@ -0,0 +1,60 @@
+package conditional
+
+import (
+	"io/fs"
+	"net/http"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestListings(t *testing.T) {
+	modTime := time.Unix(1700000000, 0)
+	fsys := fstest.MapFS{
+		"files/a.txt":         {Data: []byte("a"), ModTime: modTime},
+		"files/b <&>.txt":     {Data: []byte("b"), ModTime: modTime},
+		"files/sub/c.txt":     {Data: []byte("c"), ModTime: modTime},
+		"indexed/index.html":  {Data: []byte("index"), ModTime: modTime},
+		"indexed/hidden.html": {Data: []byte("hidden"), ModTime: modTime},
+	}
+	r := staticRouter(&Config{}, fsys, Listings())
+
+	w := request(r, Get, "/assets/files/", nil)
+	etag := w.Header().Get(ETag)
+	if w.Code != http.StatusOK || etag == "" {
+		t.Fatalf("got %d, ETag %q", w.Code, etag)
+	}
+	if want := must((&Config{}).EtagFromDirEntries(must(fs.ReadDir(fsys, "files")))); etag != want {
+		t.Errorf("ETag = %q, want the entries' ETag %q", etag, want)
+	}
+	for _, want := range []string{`<a href="/assets/files/a.txt">a.txt</a>`, `<a href="/assets/files/sub/">sub/</a>`, "b &lt;&amp;&gt;.txt"} {
+		if !strings.Contains(w.Body.String(), want) {
+			t.Errorf("listing lacks %s:\n%s", want, w.Body)
+		}
+	}
+	if got := w.Header().Get("Content-Type"); got != "text/html; charset=utf-8" {
+		t.Errorf("Content-Type = %q", got)
+	}
+
+	if w := request(r, Get, "/assets/files/", map[string]string{IfNoneMatch: etag}); w.Code != http.StatusNotModified {
+		t.Errorf("got %d for the listing's ETag, want 304", w.Code)
+	}
+	if w := request(r, Get, "/assets/indexed/", nil); w.Body.String() != "index" {
+		t.Errorf("directory with an index.html listed: %q", w.Body)
+	}
+
+	fsys["files/d.txt"] = &fstest.MapFile{Data: []byte("d"), ModTime: modTime}
+	added := request(r, Get, "/assets/files/", map[string]string{IfNoneMatch: etag})
+	if added.Code != http.StatusOK {
+		t.Errorf("got %d after adding a file, want 200", added.Code)
+	}
+	fsys["files/d.txt"] = &fstest.MapFile{Data: []byte("d"), ModTime: modTime.Add(time.Second)}
+	if w := request(r, Get, "/assets/files/", nil); w.Header().Get(ETag) == added.Header().Get(ETag) {
+		t.Error("ETag kept after a file changed")
+	}
+
+	if w := request(staticRouter(&Config{}, fsys), Get, "/assets/files/", nil); w.Code != http.StatusTeapot {
+		t.Errorf("listed without Listings: %d", w.Code)
+	}
+}
@@ -0,0 +1,3 @@
+module github.com/itsjamie/gin-conditional/loadtest
+
+go 1.25.0
@@ -0,0 +1,190 @@
+// Replays conditional and unconditional traffic against a gin app, to
+// check the 304 ratio, latency and allocations of caching layers before
+// they reach production.
+package loadtest
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Config describes the traffic to replay.
+type Config struct {
+	// Target served in process, such as a *gin.Engine. Allocation stats
+	// are only meaningful for in process targets.
+	Handler http.Handler
+
+	// Remote target used when Handler is nil, e.g. "http://localhost:8080".
+	BaseURL string
+
+	// Client for BaseURL, http.DefaultClient when nil.
+	Client *http.Client
+
+	// Paths requested, picked at random.
+	Paths []string
+
+	// Fraction of requests revalidating with the validators the path last
+	// responded with.
+	ConditionalRatio float64
+
+	// Total number of requests, and how many are in flight at once.
+	Requests    int
+	Concurrency int
+}
+
+// Report summarises a run.
+type Report struct {
+	Requests    int
+	Conditional int
+	NotModified int
+	Errors      int
+	Statuses    map[int]int
+
+	Duration      time.Duration
+	P50, P90, P99 time.Duration
+
+	AllocsPerRequest float64
+	BytesPerRequest  float64
+}
+
+// NotModifiedRatio is the fraction of conditional requests answered 304.
+func (r Report) NotModifiedRatio() float64 {
+	if r.Conditional == 0 {
+		return 0
+	}
+	return float64(r.NotModified) / float64(r.Conditional)
+}
+
+type validators struct {
+	etag, lastModified string
+}
+
+// Run replays the traffic described by cfg until done or ctx is cancelled.
+func Run(ctx context.Context, cfg Config) (Report, error) {
+	if cfg.Handler == nil && cfg.BaseURL == "" {
+		return Report{}, errors.New("loadtest: Handler or BaseURL required")
+	}
+	if len(cfg.Paths) == 0 {
+		return Report{}, errors.New("loadtest: no Paths to request")
+	}
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = 1
+	}
+	client := cfg.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	var (
+		seen      sync.Map
+		next      atomic.Int64
+		mu        sync.Mutex
+		report    = Report{Statuses: make(map[int]int)}
+		latencies = make([]time.Duration, 0, cfg.Requests)
+		wg        sync.WaitGroup
+	)
+
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	start := time.Now()
+
+	for w := 0; w < cfg.Concurrency; w++ {
+		wg.Add(1)
+		go func(seed int64) {
+			defer wg.Done()
+			rnd := rand.New(rand.NewSource(seed))
+
+			for next.Add(1) <= int64(cfg.Requests) && ctx.Err() == nil {
+				path := cfg.Paths[rnd.Intn(len(cfg.Paths))]
+				req, err := http.NewRequestWithContext(ctx, http.MethodGet, cfg.BaseURL+path, nil)
+				if err != nil {
+					return
+				}
+
+				conditional := false
+				if v, ok := seen.Load(path); ok && rnd.Float64() < cfg.ConditionalRatio {
+					conditional = true
+					if v := v.(validators); v.etag != "" {
+						req.Header.Set("If-None-Match", v.etag)
+					} else if v.lastModified != "" {
+						req.Header.Set("If-Modified-Since", v.lastModified)
+					} else {
+						conditional = false
+					}
+				}
+
+				began := time.Now()
+				status, header, err := do(client, cfg.Handler, req)
+				elapsed := time.Since(began)
+
+				if err == nil && status == http.StatusOK {
+					seen.Store(path, validators{header.Get("ETag"), header.Get("Last-Modified")})
+				}
+
+				mu.Lock()
+				report.Requests++
+				latencies = append(latencies, elapsed)
+				if err != nil {
+					report.Errors++
+				} else {
+					report.Statuses[status]++
+				}
+				if conditional {
+					report.Conditional++
+					if status == http.StatusNotModified {
+						report.NotModified++
+					}
+				}
+				mu.Unlock()
+			}
+		}(int64(w) + 1)
+	}
+	wg.Wait()
+
+	report.Duration = time.Since(start)
+	runtime.ReadMemStats(&after)
+	if report.Requests > 0 {
+		report.AllocsPerRequest = float64(after.Mallocs-before.Mallocs) / float64(report.Requests)
+		report.BytesPerRequest = float64(after.TotalAlloc-before.TotalAlloc) / float64(report.Requests)
+	}
+
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	report.P50 = percentile(latencies, 0.50)
+	report.P90 = percentile(latencies, 0.90)
+	report.P99 = percentile(latencies, 0.99)
+
+	return report, ctx.Err()
+}
+
+func do(client *http.Client, handler http.Handler, req *http.Request) (int, http.Header, error) {
+	if handler != nil {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code, rec.Header(), nil
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	return resp.StatusCode, resp.Header, nil
+}
+
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	return sorted[int(float64(len(sorted)-1)*p)]
+}
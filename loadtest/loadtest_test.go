@@ -0,0 +1,154 @@
+package loadtest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// Answers 304 when If-None-Match carries the path's ETag, and serves
+// /modified with Last-Modified only.
+var app = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/modified" {
+		lm := time.Unix(1e9, 0).UTC().Format(http.TimeFormat)
+		w.Header().Set("Last-Modified", lm)
+		if r.Header.Get("If-Modified-Since") == lm {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Write([]byte("body"))
+		return
+	}
+
+	etag := `"` + r.URL.Path + `"`
+	w.Header().Set("ETag", etag)
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Write([]byte("body"))
+})
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name  string
+		ratio float64
+	}{
+		{"unconditional", 0},
+		{"conditional", 1},
+		{"mixed", 0.5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := Run(context.Background(), Config{
+				Handler:          app,
+				Paths:            []string{"/a", "/b", "/modified"},
+				ConditionalRatio: tt.ratio,
+				Requests:         200,
+				Concurrency:      4,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if report.Requests != 200 || report.Errors != 0 {
+				t.Errorf("%d requests with %d errors, want 200 without", report.Requests, report.Errors)
+			}
+			if got := report.Statuses[http.StatusOK] + report.Statuses[http.StatusNotModified]; got != 200 {
+				t.Errorf("statuses %v", report.Statuses)
+			}
+			if report.NotModified != report.Statuses[http.StatusNotModified] {
+				t.Errorf("%d not modified, %d 304s", report.NotModified, report.Statuses[http.StatusNotModified])
+			}
+			switch {
+			case tt.ratio == 0 && report.Conditional != 0:
+				t.Errorf("%d conditional requests, want none", report.Conditional)
+			case tt.ratio > 0 && (report.Conditional == 0 || report.NotModifiedRatio() != 1):
+				t.Errorf("%d conditional requests, %v answered 304", report.Conditional, report.NotModifiedRatio())
+			}
+			if report.P50 > report.P90 || report.P90 > report.P99 {
+				t.Errorf("percentiles out of order: %v %v %v", report.P50, report.P90, report.P99)
+			}
+		})
+	}
+}
+
+func TestRunBaseURL(t *testing.T) {
+	srv := httptest.NewServer(app)
+	defer srv.Close()
+
+	report, err := Run(context.Background(), Config{
+		BaseURL:          srv.URL,
+		Client:           srv.Client(),
+		Paths:            []string{"/a"},
+		ConditionalRatio: 1,
+		Requests:         20,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Every request but the first revalidates.
+	if report.Requests != 20 || report.Conditional != 19 || report.NotModified != 19 {
+		t.Errorf("%d requests, %d conditional, %d not modified, want 20, 19, 19",
+			report.Requests, report.Conditional, report.NotModified)
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	srv := httptest.NewServer(app)
+	url := srv.URL
+	srv.Close()
+
+	report, err := Run(context.Background(), Config{BaseURL: url, Paths: []string{"/a"}, Requests: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Errors != 3 || len(report.Statuses) != 0 {
+		t.Errorf("%d errors with statuses %v, want 3 errors", report.Errors, report.Statuses)
+	}
+}
+
+func TestRunCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	report, err := Run(ctx, Config{Handler: app, Paths: []string{"/a"}, Requests: 10})
+	if err != context.Canceled || report.Requests != 0 {
+		t.Errorf("%d requests, %v, want none and context.Canceled", report.Requests, err)
+	}
+}
+
+func TestRunConfig(t *testing.T) {
+	for _, cfg := range []Config{
+		{Paths: []string{"/a"}, Requests: 1},
+		{Handler: app, Requests: 1},
+	} {
+		if _, err := Run(context.Background(), cfg); err == nil {
+			t.Errorf("%+v: no error", cfg)
+		}
+	}
+}
+
+func TestNotModifiedRatio(t *testing.T) {
+	if got := (Report{}).NotModifiedRatio(); got != 0 {
+		t.Errorf("no conditional requests: %v, want 0", got)
+	}
+	if got := (Report{Conditional: 4, NotModified: 3}).NotModifiedRatio(); got != 0.75 {
+		t.Errorf("got %v, want 0.75", got)
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	sorted := make([]time.Duration, 100)
+	for i := range sorted {
+		sorted[i] = time.Duration(i)
+	}
+	if got := percentile(nil, 0.5); got != 0 {
+		t.Errorf("empty: %v", got)
+	}
+	if p50, p99 := percentile(sorted, 0.5), percentile(sorted, 0.99); p50 != 49 || p99 != 98 {
+		t.Errorf("p50 %v, p99 %v, want 49 and 98", p50, p99)
+	}
+}
@@ -0,0 +1,130 @@
+package conditional
+
+import (
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LongPoll answers a GET or HEAD like Conditional, except that a client
+// whose If-None-Match already names the current representation is held
+// until the resource changes, as signalled by ResourceChanged for the
+// request's key, and then served it:
+//
+//	r.GET("/jobs/:id", func(c *gin.Context) {
+//		handled, err := conditional.LongPoll(c, 30*time.Second, func() (interface{}, error) {
+//			return loadJob(c.Param("id"))
+//		})
+//		...
+//	})
+//
+// load returns the current resource, a new value after each change since
+// validators are memoized per resource. After timeout, when the client
+// goes away, or once the Config is closed, the client gets its 304.
+func LongPoll(c *gin.Context, timeout time.Duration, load func() (interface{}, error)) (bool, error) {
+	return ConfigOf(c).LongPoll(c, timeout, load)
+}
+
+func (cfg *Config) LongPoll(c *gin.Context, timeout time.Duration, load func() (interface{}, error)) (bool, error) {
+	key := cfg.key(c)
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		// Watching before loading, a change in between is not missed.
+		w := cfg.changes.watch(key)
+		resource, err := load()
+		if err != nil {
+			cfg.changes.release(key, w)
+			return false, err
+		}
+		if !cfg.holdsCurrent(c, resource) || !cfg.shutdown.enter() {
+			cfg.changes.release(key, w)
+			return cfg.Conditional(c, resource)
+		}
+
+		changed := false
+		select {
+		case <-w.done:
+			changed = true
+		case <-timer.C:
+		case <-cfg.Done():
+		case <-c.Request.Context().Done():
+		}
+		cfg.changes.release(key, w)
+		if changed {
+			cfg.shutdown.exit()
+			continue
+		}
+
+		// Close waits until the 304 is written.
+		handled, err := cfg.Conditional(c, resource)
+		cfg.shutdown.exit()
+		return handled, err
+	}
+}
+
+// Reports whether the request's If-None-Match names the current
+// representation of resource, so it would be answered with 304.
+func (cfg *Config) holdsCurrent(c *gin.Context, resource interface{}) bool {
+	if c.Request.Method != Get && c.Request.Method != Head {
+		return false
+	}
+	ifNoneMatch, err := cfg.etags(c.Request, IfNoneMatch, nil)
+	if err != nil || !ifNoneMatch.present() {
+		return false
+	}
+	r := cfg.resolve(c, resource)
+	r.lenient = cfg.LenientEtags
+	if r.etag == nil || resourceAbsent(resource, r) {
+		return false
+	}
+	proceed, err := handleIfNoneMatch(&r, ifNoneMatch)
+	return err == nil && !proceed
+}
+
+// changes signals ResourceChanged to LongPoll waiters, closing the channel
+// of a key when it changes. Keys are only kept while watched.
+type changes struct {
+	mu   sync.Mutex
+	keys map[string]*change
+}
+
+type change struct {
+	done    chan struct{}
+	waiters int
+}
+
+func (ch *changes) watch(key string) *change {
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+	if ch.keys == nil {
+		ch.keys = make(map[string]*change)
+	}
+	w, ok := ch.keys[key]
+	if !ok {
+		w = &change{done: make(chan struct{})}
+		ch.keys[key] = w
+	}
+	w.waiters++
+	return w
+}
+
+func (ch *changes) release(key string, w *change) {
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+	w.waiters--
+	if w.waiters == 0 && ch.keys[key] == w {
+		delete(ch.keys, key)
+	}
+}
+
+func (ch *changes) notify(key string) {
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+	if w, ok := ch.keys[key]; ok {
+		close(w.done)
+		delete(ch.keys, key)
+	}
+}
@@ -0,0 +1,70 @@
+package conditional
+
+import (
+	"encoding/json"
+	"io"
+	"io/fs"
+	"strings"
+	"time"
+)
+
+// Manifest holds validators computed at build time, keyed by file path
+// relative to the served root, so files need not be hashed at runtime.
+// It is read from JSON like:
+//
+//	{"app.js": {"etag": "3f9c2d", "size": 5120, "mtime": "2024-01-02T15:04:05Z"}}
+type Manifest map[string]ManifestEntry
+
+// ManifestEntry describes one file. Unquoted ETags are quoted; Size and
+// ModTime are optional.
+type ManifestEntry struct {
+	ETag    string    `json:"etag"`
+	Size    int64     `json:"size,omitempty"`
+	ModTime time.Time `json:"mtime,omitempty"`
+}
+
+// LoadManifest decodes a JSON manifest from r.
+func LoadManifest(r io.Reader) (Manifest, error) {
+	var m Manifest
+	if err := json.NewDecoder(r).Decode(&m); err != nil {
+		return nil, err
+	}
+
+	manifest := make(Manifest, len(m))
+	for name, entry := range m {
+		if entry.ETag != "" {
+			entry.ETag = normalizeEtag(entry.ETag)
+		}
+		manifest[strings.TrimPrefix(name, "/")] = entry
+	}
+	return manifest, nil
+}
+
+// ReadManifest loads the JSON manifest name from fsys, typically embedded
+// next to the assets it describes.
+func ReadManifest(fsys fs.FS, name string) (Manifest, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return LoadManifest(f)
+}
+
+// WithManifest makes StaticFS take the validators of the files listed in
+// m from it. An entry whose Size differs from the file on disk is stale
+// and ignored.
+func WithManifest(m Manifest) Option {
+	return func(s *fileServer) {
+		s.manifest = m
+	}
+}
+
+// Returns the manifest entry for a file, if it has a current one.
+func (m Manifest) lookup(name string, fi fs.FileInfo) (ManifestEntry, bool) {
+	entry, ok := m[name]
+	if !ok || entry.ETag == "" || entry.Size != 0 && entry.Size != fi.Size() {
+		return ManifestEntry{}, false
+	}
+	return entry, true
+}
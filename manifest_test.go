@@ -0,0 +1,74 @@
+package conditional
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestLoadManifest(t *testing.T) {
+	m, err := LoadManifest(strings.NewReader(`{
+		"/app.js": {"etag": "3f9c2d", "size": 3},
+		"site.css": {"etag": "W/\"v1\"", "mtime": "2024-01-02T15:04:05Z"}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := m["app.js"].ETag; got != `"3f9c2d"` {
+		t.Errorf("app.js ETag = %q, want it quoted", got)
+	}
+	if got := m["site.css"]; got.ETag != `W/"v1"` || !got.ModTime.Equal(time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)) {
+		t.Errorf("site.css = %+v", got)
+	}
+
+	if _, err := LoadManifest(strings.NewReader("[")); err == nil {
+		t.Error("no error for malformed JSON")
+	}
+}
+
+func TestManifestLookup(t *testing.T) {
+	modTime := time.Unix(1700000000, 0)
+	fsys := fstest.MapFS{
+		"app.js":   {Data: []byte("app"), ModTime: modTime},
+		"stale.js": {Data: []byte("rebuilt"), ModTime: modTime},
+		"site.css": {Data: []byte("body{}"), ModTime: modTime},
+		"unlisted": {Data: []byte("x"), ModTime: modTime},
+		"asset.json": {Data: []byte(`{
+			"app.js": {"etag": "app", "size": 3},
+			"stale.js": {"etag": "stale", "size": 5},
+			"site.css": {"etag": "css", "mtime": "2024-01-02T15:04:05Z"}
+		}`)},
+	}
+	m, err := ReadManifest(fsys, "asset.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := staticRouter(&Config{}, fsys, WithManifest(m))
+
+	tests := []struct {
+		path         string
+		etag         string
+		lastModified time.Time
+	}{
+		{"/assets/app.js", `"app"`, modTime},
+		{"/assets/site.css", `"css"`, time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)},
+		// The size no longer matches, the file's own validators are used.
+		{"/assets/stale.js", EtagFromFileInfo(must(fsys.Stat("stale.js"))), modTime},
+		{"/assets/unlisted", EtagFromFileInfo(must(fsys.Stat("unlisted"))), modTime},
+	}
+	for _, tt := range tests {
+		w := request(r, Get, tt.path, nil)
+		if got := w.Header().Get(ETag); got != tt.etag {
+			t.Errorf("%s: ETag = %q, want %q", tt.path, got, tt.etag)
+		}
+		if got := w.Header().Get(LastModified); got != tt.lastModified.UTC().Format(http.TimeFormat) {
+			t.Errorf("%s: Last-Modified = %q, want %v", tt.path, got, tt.lastModified)
+		}
+		if w := request(r, Get, tt.path, map[string]string{IfNoneMatch: tt.etag}); w.Code != http.StatusNotModified {
+			t.Errorf("%s: got %d for its ETag, want 304", tt.path, w.Code)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package conditional
+
+import (
+	"reflect"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// The gin.Context key memoized validators are stored under.
+const memoKey = "conditional.memo"
+
+// Validator values computed for one Etagger or LastModifier during the
+// request, usually both as one resource.
+type memo struct {
+	source   interface{}
+	etagger  Etagger
+	modifier LastModifier
+
+	etag     string
+	etagErr  error
+	etagDone bool
+
+	lastModified     time.Time
+	lastModifiedDone bool
+}
+
+// ValidatorsOf returns the ETag and Last-Modified of resource. Like
+// Conditional, it calls the resource's Etag and LastModified at most once
+// per request, so middlewares and handlers can share validators that are
+// expensive to compute. An ETag that failed to compute is left empty.
+// Resources changed in place by the handler keep their earlier values for
+// the rest of the request.
+func ValidatorsOf(c *gin.Context, resource interface{}) Validators {
+	return ConfigOf(c).ValidatorsOf(c, resource)
+}
+
+func (cfg *Config) ValidatorsOf(c *gin.Context, resource interface{}) Validators {
+	var v Validators
+	r := cfg.resolve(c, resource)
+	if r.etag != nil {
+		if etag, err := r.etag.Etag(); err == nil {
+			v.ETag = etag
+		}
+	}
+	if r.modified != nil {
+		v.LastModified = r.modified.LastModified()
+	}
+	return v
+}
+
+// The validators of a resource for one evaluation, nil when the resource
+// has no such validator. Preconditions are compared through this concrete
+// type rather than the Etagger and LastModifier interfaces, so values held
+// on the stack, as Binding does, are evaluated without allocating.
+type resolved struct {
+	etag     *memo
+	modified *memo
+
+	// Normalize the resource's ETags, see Config.LenientEtags.
+	lenient bool
+}
+
+func (r *resolved) Etag() (string, error) {
+	etag, err := r.etag.Etag()
+	if !r.lenient || err != nil || etag == "" {
+		return etag, err
+	}
+	return normalizeEtag(etag), nil
+}
+
+func (r *resolved) LastModified() time.Time {
+	return r.modified.LastModified()
+}
+
+// Returns a memo already holding etag.
+func knownEtag(etag string) *memo {
+	return &memo{etag: etag, etagDone: true}
+}
+
+// Returns the validators of resource, memoized for the request and timed
+// when the Config asks for it.
+func (cfg *Config) resolve(c *gin.Context, resource interface{}) resolved {
+	etagger, canCheckEtag := asEtagger(c, resource)
+	modifier, canCheckModifier := resource.(LastModifier)
+
+	var r resolved
+	timedEtagger, timedModifier := cfg.timed(c, etagger, modifier)
+	if canCheckEtag {
+		r.etag = memoOf(c, etagger)
+		if r.etag.etagger == nil {
+			r.etag.etagger = timedEtagger
+		}
+	}
+	if canCheckModifier {
+		r.modified = memoOf(c, modifier)
+		if r.modified.modifier == nil {
+			r.modified.modifier = timedModifier
+		}
+	}
+	return r
+}
+
+// The memos of a request. The first few are held inline, so most
+// requests allocate once.
+type memoSet struct {
+	memos  []*memo
+	inline [2]*memo
+}
+
+// Returns the memo of source for the request. Sources that cannot be
+// compared to find them again get a memo of their own.
+func memoOf(c *gin.Context, source interface{}) *memo {
+	if !reflect.ValueOf(source).Comparable() {
+		return &memo{}
+	}
+
+	value, _ := c.Get(memoKey)
+	set, ok := value.(*memoSet)
+	if !ok {
+		set = &memoSet{}
+		set.memos = set.inline[:0]
+		c.Set(memoKey, set)
+	}
+	for _, m := range set.memos {
+		if m.source == source {
+			return m
+		}
+	}
+	m := &memo{source: source}
+	set.memos = append(set.memos, m)
+	return m
+}
+
+func (m *memo) Etag() (string, error) {
+	if !m.etagDone {
+		m.etag, m.etagErr = m.etagger.Etag()
+		m.etagDone = true
+	}
+	return m.etag, m.etagErr
+}
+
+func (m *memo) LastModified() time.Time {
+	if !m.lastModifiedDone {
+		m.lastModified = m.modifier.LastModified()
+		m.lastModifiedDone = true
+	}
+	return m.lastModified
+}
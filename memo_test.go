@@ -0,0 +1,77 @@
+package conditional
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type countingResource struct {
+	etags, dates int
+}
+
+func (r *countingResource) Etag() (string, error) {
+	r.etags++
+	return `"a"`, nil
+}
+
+func (r *countingResource) LastModified() time.Time {
+	r.dates++
+	return time.Unix(1e9, 0)
+}
+
+func TestValidatorsFetchedOnce(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(Get, "/", nil)
+	c.Request.Header.Set(IfMatch, `"a"`)
+	c.Request.Header.Set(IfNoneMatch, `"b"`)
+	c.Request.Header.Set(IfModifiedSince, "Sat, 01 Jan 2000 00:00:00 GMT")
+
+	r := &countingResource{}
+	if _, err := Conditional(c, r); err != nil {
+		t.Fatal(err)
+	}
+	ValidatorsOf(c, r)
+
+	if r.etags != 1 || r.dates != 1 {
+		t.Errorf("Etag called %d times, LastModified %d times, want 1 each", r.etags, r.dates)
+	}
+}
+
+type probedResource struct {
+	countingResource
+	exists int
+}
+
+func (r *probedResource) Exists() bool {
+	r.exists++
+	return true
+}
+
+func TestFastPathSkipsResolvers(t *testing.T) {
+	tests := []struct {
+		name   string
+		cfg    *Config
+		header map[string]string
+		etags  int
+		exists int
+	}{
+		{"plain", &Config{OmitValidators: true}, nil, 0, 0},
+		{"plain with validators", &Config{}, nil, 1, 0},
+		{"conditional", &Config{OmitValidators: true}, map[string]string{IfNoneMatch: `"b"`}, 1, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &probedResource{}
+			if _, _, err := runConditional(tt.cfg, Get, tt.header, r); err != nil {
+				t.Fatal(err)
+			}
+			if r.etags != tt.etags || r.exists != tt.exists {
+				t.Errorf("Etag called %d times, Exists %d, want %d and %d", r.etags, r.exists, tt.etags, tt.exists)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package conditional
+
+import "github.com/gin-gonic/gin"
+
+// Set on routes marked with NoStore.
+const noStoreKey = "conditional.no_store"
+
+// NoStore marks a route's responses, such as ones carrying tokens or
+// personal data, as never to be cached or revalidated: they are sent with
+// "Cache-Control: no-store", any ETag or Last-Modified the handler set is
+// removed, and Conditional never answers 304 nor sets validators. Every
+// other precondition is still evaluated, so a stale If-Match on a PUT
+// fails with 412.
+func NoStore() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		w := noStore(c)
+		c.Next()
+		w.finish()
+	}
+}
+
+// Marks the request, returning the writer to finish once the handlers
+// return; nil when it already was marked.
+func noStore(c *gin.Context) *noStoreWriter {
+	if c.GetBool(noStoreKey) {
+		return nil
+	}
+	c.Set(noStoreKey, true)
+	w := &noStoreWriter{ResponseWriter: c.Writer, cfg: ConfigOf(c)}
+	c.Writer = w
+	return w
+}
+
+// noStoreWriter rewrites the caching headers of a response just before
+// they are sent, whatever the handler set.
+type noStoreWriter struct {
+	gin.ResponseWriter
+	cfg  *Config
+	done bool
+}
+
+func (w *noStoreWriter) strip() {
+	if w.done {
+		return
+	}
+	w.done = true
+
+	header := w.Header()
+	header.Del(ETag)
+	header.Del(LastModified)
+	for _, alias := range w.cfg.Aliases {
+		if alias.Response != "" {
+			header.Del(alias.Response)
+		}
+	}
+	header.Del("Expires")
+	header.Set("Cache-Control", "no-store")
+}
+
+// Responses without a body are only written by gin after the handlers
+// return, past this writer, so their headers are rewritten here.
+func (w *noStoreWriter) finish() {
+	if w != nil && !w.Written() {
+		w.strip()
+	}
+}
+
+func (w *noStoreWriter) WriteHeader(code int) {
+	w.strip()
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *noStoreWriter) WriteHeaderNow() {
+	w.strip()
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+func (w *noStoreWriter) Write(p []byte) (int, error) {
+	w.strip()
+	return w.ResponseWriter.Write(p)
+}
+
+func (w *noStoreWriter) WriteString(s string) (int, error) {
+	w.strip()
+	return w.ResponseWriter.WriteString(s)
+}
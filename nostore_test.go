@@ -0,0 +1,73 @@
+package conditional
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func noStoreRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	resource := BytesResource([]byte("body"), `"b"`, time.Unix(1e9, 0))
+	handler := func(c *gin.Context) {
+		handled, err := Conditional(c, resource)
+		if handled {
+			return
+		}
+		if status := StatusOf(err); status != 0 {
+			c.AbortWithStatus(status)
+			return
+		}
+		if c.Request.Method == Put {
+			c.Status(http.StatusNoContent)
+			return
+		}
+		c.String(http.StatusOK, "body")
+	}
+	r.GET("/", NoStore(), handler)
+	r.PUT("/", NoStore(), handler)
+	return r
+}
+
+func TestNoStoreKeepsIfMatch(t *testing.T) {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(Put, "/", nil)
+	req.Header.Set(IfMatch, `"a"`)
+	noStoreRouter().ServeHTTP(w, req)
+
+	if w.Code != http.StatusPreconditionFailed {
+		t.Fatalf("status = %d, want 412", w.Code)
+	}
+}
+
+func TestNoStoreKeepsIfNoneMatchOnPut(t *testing.T) {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(Put, "/", nil)
+	req.Header.Set(IfNoneMatch, "*")
+	noStoreRouter().ServeHTTP(w, req)
+
+	if w.Code != http.StatusPreconditionFailed {
+		t.Fatalf("status = %d, want 412", w.Code)
+	}
+}
+
+func TestNoStoreSkipsNotModified(t *testing.T) {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(Get, "/", nil)
+	req.Header.Set(IfNoneMatch, `"b"`)
+	noStoreRouter().ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", w.Code)
+	}
+	if etag := w.Header().Get(ETag); etag != "" {
+		t.Errorf("ETag = %q, want none", etag)
+	}
+	if cc := w.Header().Get("Cache-Control"); cc != "no-store" {
+		t.Errorf("Cache-Control = %q, want no-store", cc)
+	}
+}
@@ -0,0 +1,78 @@
+package conditional
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Headers a 304 must carry when a 200 for the same request would have.
+// https://tools.ietf.org/html/rfc7232#section-4.1
+var notModifiedHeaders = []string{
+	"Cache-Control",
+	"Content-Location",
+	"Date",
+	ETag,
+	"Expires",
+	"Vary",
+}
+
+// Representation metadata describing a body a 304 does not send.
+var notModifiedStripped = []string{
+	"Content-Length",
+	"Content-Type",
+	"Content-Encoding",
+}
+
+// ResponseHeaderer can be implemented by resources that know the headers
+// a full response would carry, so they can be copied onto a 304.
+type ResponseHeaderer interface {
+	ResponseHeader() http.Header
+}
+
+// NotModified aborts the request with 304 (Not Modified), filling in the
+// headers a 200 would have carried. Fields are taken from the headers the
+// handler already set, the resource's ResponseHeader, and its validators.
+func NotModified(c *gin.Context, resource interface{}) {
+	ConfigOf(c).NotModified(c, resource)
+}
+
+func (cfg *Config) NotModified(c *gin.Context, resource interface{}) {
+	cfg.notModifiedHeader(c, resource, cfg.resolve(c, resource))
+	c.AbortWithStatus(http.StatusNotModified)
+}
+
+// Sets the headers of a 304 for resource, whose validators are r.
+func (cfg *Config) notModifiedHeader(c *gin.Context, resource interface{}, r resolved) {
+	header := c.Writer.Header()
+
+	if r, ok := resource.(ResponseHeaderer); ok {
+		src := r.ResponseHeader()
+		for _, key := range notModifiedHeaders {
+			if values := src.Values(key); len(values) > 0 {
+				header.Del(key)
+				for _, v := range values {
+					header.Add(key, v)
+				}
+			}
+		}
+	}
+
+	cfg.setValidators(c, resource, r)
+
+	if cfg.ContentLocation && header.Get("Content-Location") == "" {
+		header.Set("Content-Location", cfg.CanonicalTarget(c.Request))
+	}
+
+	if header.Get("Date") == "" {
+		header.Set("Date", time.Now().UTC().Format(http.TimeFormat))
+	}
+
+	cfg.cacheStatus(c, true)
+
+	cfg.notModifiedSaved(c, resource)
+	for _, key := range notModifiedStripped {
+		header.Del(key)
+	}
+}
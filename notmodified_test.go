@@ -0,0 +1,102 @@
+package conditional
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// headeredBytes is a resource knowing the headers of its full response.
+type headeredBytes struct {
+	*Bytes
+	header http.Header
+}
+
+func (b headeredBytes) ResponseHeader() http.Header {
+	return b.header
+}
+
+func TestNotModified(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	modified := time.Date(2001, 9, 9, 1, 46, 40, 0, time.UTC)
+	date := "Mon, 10 Sep 2001 00:00:00 GMT"
+
+	tests := []struct {
+		name     string
+		cfg      *Config
+		resource interface{}
+		set      http.Header
+		want     http.Header
+	}{
+		{
+			name:     "validators",
+			cfg:      &Config{},
+			resource: BytesResource(nil, `"a"`, modified),
+			want:     http.Header{ETag: {`"a"`}, LastModified: {modified.Format(http.TimeFormat)}},
+		},
+		{
+			name:     "handler headers kept",
+			cfg:      &Config{},
+			resource: BytesResource(nil, `"a"`, time.Time{}),
+			set:      http.Header{"Cache-Control": {"max-age=60"}, "Vary": {"Accept"}, "Date": {date}},
+			want:     http.Header{ETag: {`"a"`}, "Cache-Control": {"max-age=60"}, "Vary": {"Accept"}, "Date": {date}},
+		},
+		{
+			name: "resource headers",
+			cfg:  &Config{},
+			resource: headeredBytes{BytesResource(nil, `"a"`, time.Time{}), http.Header{
+				"Cache-Control": {"private"},
+				"Expires":       {date},
+				"X-Other":       {"not copied"},
+			}},
+			set:  http.Header{"Cache-Control": {"public"}},
+			want: http.Header{ETag: {`"a"`}, "Cache-Control": {"private"}, "Expires": {date}, "X-Other": nil},
+		},
+		{
+			name:     "body metadata stripped",
+			cfg:      &Config{},
+			resource: BytesResource(nil, `"a"`, time.Time{}),
+			set:      http.Header{"Content-Length": {"10"}, "Content-Type": {"text/plain"}, "Content-Encoding": {"gzip"}},
+			want:     http.Header{"Content-Length": nil, "Content-Type": nil, "Content-Encoding": nil},
+		},
+		{
+			name:     "omitted validators",
+			cfg:      &Config{OmitValidators: true},
+			resource: BytesResource(nil, `"a"`, modified),
+			want:     http.Header{ETag: nil, LastModified: nil},
+		},
+		{
+			name:     "content location",
+			cfg:      &Config{ContentLocation: true},
+			resource: BytesResource(nil, `"a"`, time.Time{}),
+			want:     http.Header{"Content-Location": {"http://example.com/page"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(Get, "/page", nil)
+			for key, values := range tt.set {
+				c.Writer.Header()[key] = values
+			}
+
+			tt.cfg.NotModified(c, tt.resource)
+
+			if w.Code != http.StatusNotModified || !c.IsAborted() {
+				t.Errorf("got %d, aborted %v, want an aborted 304", w.Code, c.IsAborted())
+			}
+			for key, values := range tt.want {
+				if got := w.Header().Values(key); len(got) != len(values) || len(values) > 0 && got[0] != values[0] {
+					t.Errorf("%s = %q, want %q", key, got, values)
+				}
+			}
+			if w.Header().Get("Date") == "" {
+				t.Error("no Date")
+			}
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package conditional
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// The gin.Context key the Evaluation of the request is stored under.
+const EvaluationKey = "conditional.evaluation"
+
+// Comparison is how a precondition compared the client's validator with
+// the resource's.
+type Comparison string
+
+const (
+	// Entity-tags equal character by character, neither weak.
+	StrongComparison Comparison = "strong"
+
+	// Entity-tags equal regardless of either being weak.
+	WeakComparison Comparison = "weak"
+
+	// Dates compared at a resolution of one second.
+	DateComparison Comparison = "date"
+)
+
+// Evaluation describes how Conditional decided a request. It is stored
+// on the gin.Context, see EvaluationOf, and passed to Observers.
+type Evaluation struct {
+	// Precondition header that decided the outcome, empty when the
+	// request carried none the resource could evaluate.
+	Header string
+
+	// How Header was compared.
+	Comparison Comparison
+
+	// Value of Header on the request.
+	ClientValue string
+
+	// Validators of the resource, as set on the response.
+	ETag         string
+	LastModified time.Time
+
+	// Time spent in the resource's Etag and LastModified, when the
+	// Config has TimeValidators.
+	ValidatorTime time.Duration
+
+	// Status the request is answered with, such as 304 or 412, or 0
+	// when it proceeds to the handler. For errors, the status the caller
+	// is expected to answer with.
+	Status int
+
+	// Precondition headers that could not be parsed, whether the Config
+	// ignored them or failed the request.
+	Malformed []string
+
+	// Error returned by Conditional, if any.
+	Err error
+}
+
+// Outcome names the decision: "not-modified", "precondition-failed",
+// "range-ignored", "proceed", or "error" for any other status.
+func (e Evaluation) Outcome() string {
+	switch {
+	case e.Status == http.StatusNotModified:
+		return "not-modified"
+	case e.Status == http.StatusPreconditionFailed:
+		return "precondition-failed"
+	case errors.Is(e.Err, ErrRangeMismatch):
+		return "range-ignored"
+	case e.Status == 0:
+		return "proceed"
+	}
+	return "error"
+}
+
+// Observer is told about every evaluation of the Config it is listed in,
+// to record it in traces, logs or metrics.
+type Observer interface {
+	Observe(c *gin.Context, e Evaluation)
+}
+
+// EvaluationOf returns the Evaluation of the request's last call to
+// Conditional, and false when none was made.
+func EvaluationOf(c *gin.Context) (Evaluation, bool) {
+	value, ok := c.Get(EvaluationKey)
+	e, _ := value.(Evaluation)
+	return e, ok
+}
+
+// Completes e from the request and response, stores it on c, then
+// notifies Observers.
+func (cfg *Config) observe(c *gin.Context, e Evaluation, err error) Evaluation {
+	if e.Header != "" {
+		e.ClientValue = cfg.header(c.Request, e.Header)
+	}
+
+	header := c.Writer.Header()
+	e.ETag = header.Get(ETag)
+	e.LastModified, _ = http.ParseTime(header.Get(LastModified))
+
+	e.ValidatorTime = validatorTime(c)
+
+	e.Err = err
+	if e.Status == 0 {
+		e.Status = errorStatus(err)
+	}
+
+	c.Set(EvaluationKey, e)
+	setLogKeys(c, e)
+	cfg.trace(c, e)
+	for _, o := range cfg.Observers {
+		o.Observe(c, e)
+	}
+	if cfg.Logger != nil {
+		cfg.logEvaluation(c, e)
+	}
+	return e
+}
+
+// Request headers logged as the client's validators.
+var clientValidators = []string{IfMatch, IfNoneMatch, IfModifiedSince, IfUnmodifiedSince, IfRange}
+
+func (cfg *Config) logEvaluation(c *gin.Context, e Evaluation) {
+	ctx := c.Request.Context()
+	if !cfg.Logger.Enabled(ctx, slog.LevelDebug) {
+		return
+	}
+
+	var client []any
+	for _, name := range clientValidators {
+		if value := cfg.header(c.Request, name); value != "" {
+			client = append(client, slog.String(name, value))
+		}
+	}
+
+	attrs := []any{
+		slog.String("method", c.Request.Method),
+		slog.String("path", c.Request.URL.Path),
+		slog.Group("client", client...),
+		slog.Group("server", slog.String(ETag, e.ETag), slog.Time(LastModified, e.LastModified)),
+		slog.String("header", e.Header),
+		slog.String("comparison", string(e.Comparison)),
+		slog.String("outcome", e.Outcome()),
+		slog.Int("status", e.Status),
+	}
+	if e.Err != nil {
+		attrs = append(attrs, slog.String("error", e.Err.Error()))
+	}
+	cfg.Logger.DebugContext(ctx, "conditional: evaluated", attrs...)
+}
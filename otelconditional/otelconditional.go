@@ -0,0 +1,79 @@
+// Records the decisions of gin-conditional on OpenTelemetry spans, such
+// as the server span otelgin starts for each request.
+package otelconditional
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	conditional "github.com/itsjamie/gin-conditional"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Attribute keys set on the span.
+const (
+	HeaderKey       = attribute.Key("conditional.header")
+	ComparisonKey   = attribute.Key("conditional.comparison")
+	OutcomeKey      = attribute.Key("conditional.outcome")
+	StatusKey       = attribute.Key("conditional.status")
+	ClientValueKey  = attribute.Key("conditional.client_validator")
+	ETagKey         = attribute.Key("conditional.etag")
+	LastModifiedKey = attribute.Key("conditional.last_modified")
+
+	// Seconds spent in the resource's validators, with TimeValidators.
+	ValidatorTimeKey = attribute.Key("conditional.validator_time")
+
+	// Body bytes not sent because of a 304 or 206.
+	BytesSavedKey = attribute.Key("conditional.bytes_saved")
+)
+
+// Observer is a conditional.Observer adding the evaluation's attributes
+// to the span of the request context, along with a "conditional.evaluate"
+// event. Validator values are hashed, so traces do not leak them:
+//
+//	cfg.Observers = append(cfg.Observers, otelconditional.Observer{})
+type Observer struct{}
+
+func (Observer) Observe(c *gin.Context, e conditional.Evaluation) {
+	span := trace.SpanFromContext(c.Request.Context())
+	if !span.IsRecording() {
+		return
+	}
+
+	attrs := []attribute.KeyValue{
+		OutcomeKey.String(e.Outcome()),
+		StatusKey.Int(e.Status),
+	}
+	if e.Header != "" {
+		attrs = append(attrs,
+			HeaderKey.String(e.Header),
+			ComparisonKey.String(string(e.Comparison)),
+			ClientValueKey.String(hash(e.ClientValue)))
+	}
+	if e.ETag != "" {
+		attrs = append(attrs, ETagKey.String(hash(e.ETag)))
+	}
+	if !e.LastModified.IsZero() {
+		attrs = append(attrs, LastModifiedKey.String(e.LastModified.UTC().Format(http.TimeFormat)))
+	}
+	if e.ValidatorTime > 0 {
+		attrs = append(attrs, ValidatorTimeKey.Float64(e.ValidatorTime.Seconds()))
+	}
+
+	span.SetAttributes(attrs...)
+	span.AddEvent("conditional.evaluate", trace.WithAttributes(attrs...))
+}
+
+// BytesSaved implements conditional.SavingsObserver.
+func (Observer) BytesSaved(c *gin.Context, n int64) {
+	trace.SpanFromContext(c.Request.Context()).SetAttributes(BytesSavedKey.Int64(n))
+}
+
+// Hashes a validator, keeping enough to tell values apart.
+func hash(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:8])
+}
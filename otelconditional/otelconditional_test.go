@@ -0,0 +1,145 @@
+package otelconditional
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	conditional "github.com/itsjamie/gin-conditional"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// Serves a 10 byte resource under a span recorded by the returned
+// recorder, with Observer listed in the Config.
+func tracedRouter(t *testing.T) (*gin.Engine, *tracetest.SpanRecorder) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	sr := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr)).Tracer("test")
+
+	cfg := &conditional.Config{Observers: []conditional.Observer{Observer{}}}
+	resource := conditional.BytesResource([]byte("0123456789"), `"a"`, time.Unix(1e9, 0))
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		ctx, span := tracer.Start(c.Request.Context(), c.FullPath())
+		defer span.End()
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	})
+	r.GET("/", conditional.Policy{Config: cfg}.Handler(), func(c *gin.Context) {
+		cfg.Serve(c, resource)
+	})
+	return r, sr
+}
+
+func attributes(kvs []attribute.KeyValue) map[attribute.Key]attribute.Value {
+	m := make(map[attribute.Key]attribute.Value, len(kvs))
+	for _, kv := range kvs {
+		m[kv.Key] = kv.Value
+	}
+	return m
+}
+
+func serve(r http.Handler, header map[string]string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestObserver(t *testing.T) {
+	r, sr := tracedRouter(t)
+	if w := serve(r, map[string]string{"If-None-Match": `"a"`}); w.Code != http.StatusNotModified {
+		t.Fatalf("got %d, want 304", w.Code)
+	}
+
+	spans := sr.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("%d spans, want 1", len(spans))
+	}
+	attrs := attributes(spans[0].Attributes())
+	for key, want := range map[attribute.Key]attribute.Value{
+		OutcomeKey:      attribute.StringValue("not-modified"),
+		StatusKey:       attribute.IntValue(http.StatusNotModified),
+		HeaderKey:       attribute.StringValue("If-None-Match"),
+		ClientValueKey:  attribute.StringValue(hash(`"a"`)),
+		ETagKey:         attribute.StringValue(hash(`"a"`)),
+		LastModifiedKey: attribute.StringValue("Sun, 09 Sep 2001 01:46:40 GMT"),
+		BytesSavedKey:   attribute.Int64Value(10),
+	} {
+		if got := attrs[key]; got != want {
+			t.Errorf("%s = %v, want %v", key, got.Emit(), want.Emit())
+		}
+	}
+	if _, ok := attrs[ComparisonKey]; !ok {
+		t.Errorf("no %s", ComparisonKey)
+	}
+	if _, ok := attrs[ValidatorTimeKey]; ok {
+		t.Errorf("%s set without TimeValidators", ValidatorTimeKey)
+	}
+
+	events := spans[0].Events()
+	if len(events) != 1 || events[0].Name != "conditional.evaluate" {
+		t.Fatalf("events %v, want one conditional.evaluate", events)
+	}
+	if got := attributes(events[0].Attributes)[OutcomeKey]; got.AsString() != "not-modified" {
+		t.Errorf("event outcome %q", got.AsString())
+	}
+}
+
+func TestObserverProceed(t *testing.T) {
+	r, sr := tracedRouter(t)
+	if w := serve(r, nil); w.Code != http.StatusOK {
+		t.Fatalf("got %d, want 200", w.Code)
+	}
+
+	attrs := attributes(sr.Ended()[0].Attributes())
+	if got := attrs[OutcomeKey].AsString(); got != "proceed" {
+		t.Errorf("outcome %q, want proceed", got)
+	}
+	for _, key := range []attribute.Key{HeaderKey, ComparisonKey, ClientValueKey, BytesSavedKey} {
+		if _, ok := attrs[key]; ok {
+			t.Errorf("%s set without a precondition", key)
+		}
+	}
+}
+
+func TestObserverRange(t *testing.T) {
+	r, sr := tracedRouter(t)
+	if w := serve(r, map[string]string{"Range": "bytes=0-3"}); w.Code != http.StatusPartialContent {
+		t.Fatalf("got %d, want 206", w.Code)
+	}
+
+	if got := attributes(sr.Ended()[0].Attributes())[BytesSavedKey].AsInt64(); got != 6 {
+		t.Errorf("%s = %d, want 6", BytesSavedKey, got)
+	}
+}
+
+// Without a recording span the Observer does nothing.
+func TestObserverNotRecording(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := &conditional.Config{Observers: []conditional.Observer{Observer{}}}
+	resource := conditional.BytesResource([]byte("0123456789"), `"a"`, time.Unix(1e9, 0))
+	r := gin.New()
+	r.GET("/", func(c *gin.Context) { cfg.Serve(c, resource) })
+
+	if w := serve(r, map[string]string{"If-None-Match": `"a"`}); w.Code != http.StatusNotModified {
+		t.Errorf("got %d, want 304", w.Code)
+	}
+}
+
+func TestHash(t *testing.T) {
+	if hash(`"a"`) == hash(`"b"`) {
+		t.Error("different validators hash alike")
+	}
+	if got := hash(`"a"`); len(got) != 16 || got == `"a"` {
+		t.Errorf("hash = %q, want 16 hex digits", got)
+	}
+}
@@ -0,0 +1,85 @@
+package conditional
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// The gin.Context key a Policy stores its Config under.
+const ConfigKey = "conditional.config"
+
+// Policy bundles the freshness headers and evaluation settings of a class
+// of routes, so they are configured once per route group:
+//
+//	api := router.Group("/api", conditional.Preset("private-api"))
+//
+// Package level functions such as Conditional and Serve use the Config of
+// the request's Policy. A CacheControl without directives sets no header.
+type Policy struct {
+	CacheControl CacheControl
+
+	// Evaluation settings, DefaultConfig when nil.
+	Config *Config
+
+	// Treat the routes as NoStore does.
+	NoStore bool
+}
+
+// Named presets for Preset. Applications may add their own before
+// registering routes.
+var Presets = map[string]Policy{
+	// Per-user responses, revalidated on every use.
+	"private-api": {CacheControl: CacheControl{Private: true, NoCache: true}},
+
+	// Shared assets, fresh for a day, then revalidated.
+	"public-asset": {CacheControl: CacheControl{Public: true, MaxAge: 24 * time.Hour}},
+
+	// Responses no cache may keep, never revalidated.
+	"no-store": {NoStore: true},
+}
+
+// Preset returns the middleware of the named entry of Presets. It panics
+// on unknown names, like regexp.MustCompile, as routes are set up once.
+func Preset(name string) gin.HandlerFunc {
+	policy, ok := Presets[name]
+	if !ok {
+		panic(fmt.Sprintf("conditional: unknown preset %q", name))
+	}
+	return policy.Handler()
+}
+
+// Apply sets the policy's freshness headers on GET and HEAD responses, so
+// a Policy is a CachePolicy.
+func (p Policy) Apply(c *gin.Context) {
+	if c.Request.Method == Get || c.Request.Method == Head {
+		p.CacheControl.Apply(c)
+	}
+}
+
+// Handler returns a middleware applying the policy to every request it
+// handles.
+func (p Policy) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if p.Config != nil {
+			c.Set(ConfigKey, p.Config)
+		}
+		var w *noStoreWriter
+		if p.NoStore {
+			w = noStore(c)
+		}
+		p.Apply(c)
+		c.Next()
+		w.finish()
+	}
+}
+
+// ConfigOf returns the Config of the request's Policy, or DefaultConfig.
+func ConfigOf(c *gin.Context) *Config {
+	value, _ := c.Get(ConfigKey)
+	if cfg, ok := value.(*Config); ok {
+		return cfg
+	}
+	return DefaultConfig
+}
@@ -0,0 +1,67 @@
+package conditional
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ValidatorLookup returns the current validators of the requested resource
+// without loading it, typically from a ValidatorCache or ValidatorStore.
+// It returns false when they are not known, and ErrNoResource when
+// nothing exists there.
+type ValidatorLookup func(c *gin.Context) (Validators, bool, error)
+
+// Precondition returns a middleware answering 304 and 412 from validators
+// that are cheap to look up, before the handler loads or renders anything:
+//
+//	router.GET("/reports/:id", conditional.Precondition(lookup), renderReport)
+//
+// Requests whose preconditions pass continue to the handler with the
+// validators already set on the response, so it only has to write the
+// body. Requests the lookup knows nothing about continue unevaluated, for
+// the handler to evaluate once it loaded the resource.
+func Precondition(lookup ValidatorLookup) gin.HandlerFunc {
+	return DefaultConfig.Precondition(lookup)
+}
+
+func (cfg *Config) Precondition(lookup ValidatorLookup) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		v, ok, err := lookup(c)
+		var resource interface{}
+		switch {
+		case errors.Is(err, ErrNoResource):
+		case err != nil:
+			c.AbortWithError(http.StatusInternalServerError, err)
+			return
+		case !ok:
+			c.Next()
+			return
+		default:
+			resource = v.Resource()
+		}
+
+		handled, err := cfg.Conditional(c, resource)
+		if handled {
+			return
+		}
+		if status := errorStatus(err); status != 0 {
+			c.AbortWithStatus(status)
+			return
+		}
+		c.Next()
+	}
+}
+
+// StoreLookup looks validators up in store under the request's resource
+// key, see Config.Key. A store that fails is treated as not knowing them.
+func StoreLookup(store ValidatorStore) ValidatorLookup {
+	return func(c *gin.Context) (Validators, bool, error) {
+		v, ok, err := store.Get(c.Request.Context(), ConfigOf(c).key(c))
+		if err != nil {
+			return Validators{}, false, nil
+		}
+		return v, ok, nil
+	}
+}
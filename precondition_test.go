@@ -0,0 +1,87 @@
+package conditional
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestPrecondition(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	lookup := func(c *gin.Context) (Validators, bool, error) {
+		switch c.Param("id") {
+		case "known":
+			return Validators{ETag: `"a"`}, true, nil
+		case "gone":
+			return Validators{}, false, ErrNoResource
+		case "broken":
+			return Validators{}, false, errors.New("lookup failed")
+		}
+		return Validators{}, false, nil
+	}
+	cfg := &Config{}
+	r := gin.New()
+	handler := func(c *gin.Context) { c.String(http.StatusOK, "rendered") }
+	r.GET("/reports/:id", cfg.Precondition(lookup), handler)
+	r.PUT("/reports/:id", cfg.Precondition(lookup), handler)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		header map[string]string
+		status int
+		body   string
+		etag   string
+	}{
+		{"revalidated", Get, "/reports/known", map[string]string{IfNoneMatch: `"a"`}, http.StatusNotModified, "", `"a"`},
+		{"changed", Get, "/reports/known", map[string]string{IfNoneMatch: `"b"`}, http.StatusOK, "rendered", `"a"`},
+		{"plain", Get, "/reports/known", nil, http.StatusOK, "rendered", `"a"`},
+		{"unknown", Get, "/reports/new", map[string]string{IfNoneMatch: `"a"`}, http.StatusOK, "rendered", ""},
+		{"lost update", Put, "/reports/known", map[string]string{IfMatch: `"b"`}, http.StatusPreconditionFailed, "", ""},
+		{"update", Put, "/reports/known", map[string]string{IfMatch: `"a"`}, http.StatusOK, "rendered", ""},
+		{"gone", Put, "/reports/gone", map[string]string{IfMatch: "*"}, http.StatusPreconditionFailed, "", ""},
+		{"create", Put, "/reports/gone", map[string]string{IfNoneMatch: "*"}, http.StatusOK, "rendered", ""},
+		{"lookup error", Get, "/reports/broken", nil, http.StatusInternalServerError, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := request(r, tt.method, tt.path, tt.header)
+			if w.Code != tt.status || w.Body.String() != tt.body {
+				t.Errorf("got %d %q, want %d %q", w.Code, w.Body, tt.status, tt.body)
+			}
+			if got := w.Header().Get(ETag); got != tt.etag {
+				t.Errorf("ETag = %q, want %q", got, tt.etag)
+			}
+		})
+	}
+}
+
+func TestStoreLookup(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cache := NewValidatorCache(0, 0)
+	cache.Set("http://example.com/reports/1", Validators{ETag: `"a"`})
+
+	tests := []struct {
+		name   string
+		store  ValidatorStore
+		path   string
+		status int
+	}{
+		{"stored", cache.Store(), "/reports/1", http.StatusNotModified},
+		{"not stored", cache.Store(), "/reports/2", http.StatusOK},
+		{"failing store", failingStore{}, "/reports/1", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.GET("/reports/:id", Precondition(StoreLookup(tt.store)), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			if w := request(r, Get, tt.path, map[string]string{IfNoneMatch: `"a"`}); w.Code != tt.status {
+				t.Errorf("got %d, want %d", w.Code, tt.status)
+			}
+		})
+	}
+}
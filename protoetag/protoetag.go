@@ -0,0 +1,46 @@
+// Derives ETags from protobuf messages, for gRPC-transcoded and
+// proto-rendered gin endpoints.
+package protoetag
+
+import (
+	conditional "github.com/itsjamie/gin-conditional"
+	"google.golang.org/protobuf/proto"
+)
+
+// Deterministic marshaling orders map entries, so equal messages encode
+// to the same bytes within one binary.
+var marshal = proto.MarshalOptions{Deterministic: true}
+
+// Etag returns a strong ETag for m's deterministic wire encoding, using
+// conditional.DefaultConfig's hash.
+func Etag(m proto.Message) (string, error) {
+	return EtagWith(conditional.DefaultConfig, m)
+}
+
+// EtagWith is Etag using cfg's hash and encoding.
+func EtagWith(cfg *conditional.Config, m proto.Message) (string, error) {
+	b, err := marshal.Marshal(m)
+	if err != nil {
+		return "", err
+	}
+	return cfg.EtagFromBytes(b), nil
+}
+
+// Message returns an Etagger for m, for use with conditional.Conditional.
+func Message(m proto.Message) conditional.Etagger {
+	return MessageWith(conditional.DefaultConfig, m)
+}
+
+// MessageWith is Message using cfg's hash and encoding.
+func MessageWith(cfg *conditional.Config, m proto.Message) conditional.Etagger {
+	return message{cfg, m}
+}
+
+type message struct {
+	cfg *conditional.Config
+	m   proto.Message
+}
+
+func (m message) Etag() (string, error) {
+	return EtagWith(m.cfg, m.m)
+}
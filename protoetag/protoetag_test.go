@@ -0,0 +1,49 @@
+package protoetag
+
+import (
+	"testing"
+
+	conditional "github.com/itsjamie/gin-conditional"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestEtag(t *testing.T) {
+	a, err := structpb.NewStruct(map[string]interface{}{"a": 1, "b": "two", "c": true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := structpb.NewStruct(map[string]interface{}{"c": true, "b": "two", "a": 1})
+	changed, _ := structpb.NewStruct(map[string]interface{}{"a": 2, "b": "two", "c": true})
+
+	etag, err := Etag(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other, _ := Etag(b); other != etag {
+		t.Errorf("equal messages got %s and %s", etag, other)
+	}
+	if other, _ := Etag(changed); other == etag {
+		t.Error("changed message kept its ETag")
+	}
+	if other, _ := Message(a).Etag(); other != etag {
+		t.Errorf("Message ETag %s, want %s", other, etag)
+	}
+}
+
+func TestEtagWith(t *testing.T) {
+	cfg := &conditional.Config{EtagNamespace: "build-2"}
+	m := wrapperspb.String("value")
+
+	plain, _ := Etag(m)
+	namespaced, err := EtagWith(cfg, m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plain == namespaced {
+		t.Error("EtagWith ignores the Config")
+	}
+	if etag, _ := MessageWith(cfg, m).Etag(); etag != namespaced {
+		t.Errorf("MessageWith ETag %s, want %s", etag, namespaced)
+	}
+}
@@ -0,0 +1,146 @@
+package conditional
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Invalidator is told when a resource changed, so representations cached
+// elsewhere under its old validators, such as in a CDN, can be purged.
+type Invalidator interface {
+	OnResourceChanged(key string)
+}
+
+// InvalidatorFunc adapts a function to Invalidator.
+type InvalidatorFunc func(key string)
+
+func (f InvalidatorFunc) OnResourceChanged(key string) {
+	f(key)
+}
+
+// ResourceChanged tells DefaultConfig's Invalidators, and LongPoll waiters,
+// that the resource stored under key changed. GuardBuilder routes and the
+// Sidecar call it after every successful mutation.
+func ResourceChanged(key string) {
+	DefaultConfig.ResourceChanged(key)
+}
+
+func (cfg *Config) ResourceChanged(key string) {
+	cfg.changes.notify(key)
+	for _, inv := range cfg.Invalidators {
+		if a, ok := inv.(AsyncInvalidator); ok && a.Async() {
+			cfg.background(func() { inv.OnResourceChanged(key) })
+		} else {
+			inv.OnResourceChanged(key)
+		}
+	}
+}
+
+// AsyncInvalidator can be implemented by Invalidators too slow to run on
+// the request, such as CDN purges. ResourceChanged runs them in the
+// background when Async is true, and Close waits for them to finish.
+type AsyncInvalidator interface {
+	Invalidator
+	Async() bool
+}
+
+// Time allowed for one purge request.
+const purgeTimeout = 10 * time.Second
+
+// HTTPPurger purges a URL from a cache such as Varnish or nginx by
+// sending it a request, PURGE unless Method is set. With the default
+// TargetKey keys are already absolute URLs; URL maps other keys. Failures
+// are logged to ErrorLog. OnResourceChanged waits for the purge, listed in
+// Config.Invalidators it runs in the background.
+type HTTPPurger struct {
+	URL      func(key string) string
+	Method   string
+	Header   http.Header
+	Client   *http.Client
+	ErrorLog *log.Logger
+}
+
+func (p *HTTPPurger) OnResourceChanged(key string) {
+	target := key
+	if p.URL != nil {
+		target = p.URL(key)
+	}
+	method := p.Method
+	if method == "" {
+		method = "PURGE"
+	}
+	purge(p.Client, p.ErrorLog, method, target, p.Header)
+}
+
+func (p *HTTPPurger) Async() bool {
+	return true
+}
+
+// FastlyPurger purges by surrogate key through the Fastly API, so the
+// resource's responses should list its key in SurrogateKeys. Soft purges
+// mark content stale instead of removing it, letting stale-if-error
+// directives still apply. Like HTTPPurger it runs in the background when
+// listed in Config.Invalidators.
+type FastlyPurger struct {
+	ServiceID string
+	Token     string
+	Soft      bool
+	Client    *http.Client
+	ErrorLog  *log.Logger
+}
+
+func (p *FastlyPurger) OnResourceChanged(key string) {
+	header := http.Header{"Fastly-Key": {p.Token}}
+	if p.Soft {
+		header.Set("Fastly-Soft-Purge", "1")
+	}
+	target := "https://api.fastly.com/service/" + url.PathEscape(p.ServiceID) + "/purge/" + url.PathEscape(key)
+	purge(p.Client, p.ErrorLog, http.MethodPost, target, header)
+}
+
+func (p *FastlyPurger) Async() bool {
+	return true
+}
+
+func purge(client *http.Client, errorLog *log.Logger, method, target string, header http.Header) {
+	if err := sendPurge(client, method, target, header); err != nil {
+		if errorLog != nil {
+			errorLog.Printf("conditional: purging %s: %v", target, err)
+		} else {
+			log.Printf("conditional: purging %s: %v", target, err)
+		}
+	}
+}
+
+func sendPurge(client *http.Client, method, target string, header http.Header) error {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), purgeTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, method, target, nil)
+	if err != nil {
+		return err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
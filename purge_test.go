@@ -0,0 +1,68 @@
+package conditional
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPurgesTrackedByClose(t *testing.T) {
+	release := make(chan struct{})
+	var purged atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		if r.Method == "PURGE" {
+			purged.Add(1)
+		}
+	}))
+	defer server.Close()
+
+	cfg := &Config{Invalidators: []Invalidator{&HTTPPurger{URL: func(key string) string { return server.URL + key }}}}
+
+	start := time.Now()
+	cfg.ResourceChanged("/a")
+	cfg.ResourceChanged("/b")
+	if time.Since(start) > time.Second {
+		t.Fatal("ResourceChanged waited for the purges")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := cfg.Close(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Close with purges in flight = %v, want deadline exceeded", err)
+	}
+
+	close(release)
+	cfg.shutdown.running.Wait()
+	if n := purged.Load(); n != 2 {
+		t.Errorf("%d purges sent, want 2", n)
+	}
+}
+
+func TestCloseWaitsForPurges(t *testing.T) {
+	var purged atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+		purged.Add(1)
+	}))
+	defer server.Close()
+
+	cfg := &Config{Invalidators: []Invalidator{&HTTPPurger{URL: func(key string) string { return server.URL + key }}}}
+	cfg.ResourceChanged("/a")
+	if err := cfg.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if purged.Load() != 1 {
+		t.Error("Close returned before the purge completed")
+	}
+
+	// Changes after Close are purged before ResourceChanged returns.
+	cfg.ResourceChanged("/b")
+	if purged.Load() != 2 {
+		t.Error("purge after Close did not run")
+	}
+}
@@ -0,0 +1,453 @@
+package conditional
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Set on the gin.Context when If-Range failed, so range serving falls
+// back to the full representation.
+const rangeIgnoredKey = "conditional.range_ignored"
+
+var (
+	// The Range header does not follow the bytes range grammar and must
+	// be ignored.
+	ErrInvalidRange = errors.New("Invalid Range header")
+
+	// None of the requested ranges overlap the representation.
+	ErrRangeNotSatisfiable = errors.New("Requested range not satisfiable")
+)
+
+// ByteRange is a span of a representation, starting at the byte offset
+// Start.
+type ByteRange struct {
+	Start  int64
+	Length int64
+}
+
+// ContentRange formats the range for the Content-Range header of a
+// representation of size bytes.
+func (r ByteRange) ContentRange(size int64) string {
+	return fmt.Sprintf("bytes %d-%d/%d", r.Start, r.Start+r.Length-1, size)
+}
+
+// ParseRange parses a "bytes=first-last, ..." Range header against a
+// representation of size bytes, including the "first-" and "-suffix"
+// forms. Ranges past the end are dropped, or clamped when they only end
+// past it.
+// https://tools.ietf.org/html/rfc7233#section-2.1
+func ParseRange(header string, size int64) ([]ByteRange, error) {
+	unit, set, ok := strings.Cut(header, "=")
+	if !ok || !strings.EqualFold(strings.TrimSpace(unit), "bytes") {
+		return nil, ErrInvalidRange
+	}
+
+	var ranges []ByteRange
+	for _, spec := range strings.Split(set, ",") {
+		spec = strings.TrimSpace(spec)
+		if spec == "" {
+			continue
+		}
+
+		first, last, ok := strings.Cut(spec, "-")
+		if !ok {
+			return nil, ErrInvalidRange
+		}
+		first, last = strings.TrimSpace(first), strings.TrimSpace(last)
+
+		// A suffix range, "-500" asks for the last 500 bytes.
+		if first == "" {
+			n, err := strconv.ParseInt(last, 10, 64)
+			if err != nil || n < 0 {
+				return nil, ErrInvalidRange
+			}
+			if n == 0 || size == 0 {
+				continue
+			}
+			n = min(n, size)
+			ranges = append(ranges, ByteRange{Start: size - n, Length: n})
+			continue
+		}
+
+		start, err := strconv.ParseInt(first, 10, 64)
+		if err != nil || start < 0 {
+			return nil, ErrInvalidRange
+		}
+
+		// An open-ended range, "500-" runs to the end.
+		end := size - 1
+		if last != "" {
+			if end, err = strconv.ParseInt(last, 10, 64); err != nil || end < start {
+				return nil, ErrInvalidRange
+			}
+		}
+
+		if start >= size {
+			continue
+		}
+		if end >= size {
+			end = size - 1
+		}
+		ranges = append(ranges, ByteRange{Start: start, Length: end - start + 1})
+	}
+
+	if len(ranges) == 0 {
+		return nil, wrapError(ErrRangeNotSatisfiable, Range)
+	}
+	return ranges, nil
+}
+
+// ServeRange writes content, a representation of size bytes, honouring a
+// range request with 206 (Partial Content), or 416 (Range Not Satisfiable)
+// when no range overlaps the representation. Several ranges are sent as a
+// multipart/byteranges body, whose parts use the Content-Type already set
+// on the response. Any other request, or a Range that failed If-Range or
+// cannot be used, gets the full representation with a 200. Preconditions
+// must have been evaluated first.
+func ServeRange(c *gin.Context, content io.ReadSeeker, size int64) {
+	ConfigOf(c).ServeRange(c, content, size)
+}
+
+func (cfg *Config) ServeRange(c *gin.Context, content io.ReadSeeker, size int64) {
+	header := c.Writer.Header()
+	if header.Get(AcceptRanges) == "" {
+		setAcceptRanges(header, !cfg.NoRanges && (cfg.EncodedContent || !encodingActive(header)))
+	}
+
+	ranges, err := cfg.requestedRanges(c, size)
+	if errors.Is(err, ErrRangeNotSatisfiable) {
+		header.Set("Content-Range", fmt.Sprintf("bytes */%d", size))
+		c.AbortWithStatus(http.StatusRequestedRangeNotSatisfiable)
+		return
+	}
+
+	if len(ranges) == 1 {
+		r := ranges[0]
+		if _, err := content.Seek(r.Start, io.SeekStart); err != nil {
+			c.AbortWithError(http.StatusInternalServerError, err)
+			return
+		}
+
+		header.Set("Content-Range", r.ContentRange(size))
+		header.Set("Content-Length", strconv.FormatInt(r.Length, 10))
+		c.Status(http.StatusPartialContent)
+		cfg.saved(c, size-r.Length)
+		cfg.writeBody(c, content, r.Length)
+		return
+	}
+
+	if len(ranges) > 1 {
+		cfg.serveMultipart(c, content, size, ranges)
+		return
+	}
+
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	header.Set("Content-Length", strconv.FormatInt(size, 10))
+	c.Status(http.StatusOK)
+	cfg.writeBody(c, content, size)
+}
+
+// Writes ranges as the parts of a multipart/byteranges body.
+// https://tools.ietf.org/html/rfc7233#appendix-A
+func (cfg *Config) serveMultipart(c *gin.Context, content io.ReadSeeker, size int64, ranges []ByteRange) {
+	header := c.Writer.Header()
+	contentType := header.Get("Content-Type")
+
+	mw := multipart.NewWriter(c.Writer)
+	partHeaders := make([]textproto.MIMEHeader, len(ranges))
+	for i, r := range ranges {
+		partHeaders[i] = textproto.MIMEHeader{"Content-Range": {r.ContentRange(size)}}
+		if contentType != "" {
+			partHeaders[i].Set("Content-Type", contentType)
+		}
+	}
+
+	header.Set("Content-Type", "multipart/byteranges; boundary="+mw.Boundary())
+	length := multipartLength(mw.Boundary(), partHeaders, ranges)
+	header.Set("Content-Length", strconv.FormatInt(length, 10))
+	c.Status(http.StatusPartialContent)
+	cfg.saved(c, size-length)
+	c.Writer.WriteHeaderNow()
+	if c.Request.Method == Head {
+		return
+	}
+
+	for i, r := range ranges {
+		part, err := mw.CreatePart(partHeaders[i])
+		if err != nil {
+			return
+		}
+		if _, err := content.Seek(r.Start, io.SeekStart); err != nil {
+			return
+		}
+		if err := cfg.copyBody(c, part, content, r.Length); err != nil {
+			return
+		}
+	}
+	mw.Close()
+}
+
+// Computes the size of a multipart/byteranges body by writing its framing
+// without the part bodies.
+func multipartLength(boundary string, partHeaders []textproto.MIMEHeader, ranges []ByteRange) int64 {
+	var counter countingWriter
+	mw := multipart.NewWriter(&counter)
+	mw.SetBoundary(boundary)
+
+	length := int64(0)
+	for i, r := range ranges {
+		mw.CreatePart(partHeaders[i])
+		length += r.Length
+	}
+	mw.Close()
+	return length + int64(counter)
+}
+
+type countingWriter int64
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	*w += countingWriter(len(p))
+	return len(p), nil
+}
+
+// Returns the ranges to serve, nil when the full representation is to be
+// sent. Only ErrRangeNotSatisfiable is reported, an invalid Range header
+// is ignored.
+func (cfg *Config) requestedRanges(c *gin.Context, size int64) ([]ByteRange, error) {
+	if c.Request.Method != Get && c.Request.Method != Head {
+		return nil, nil
+	}
+	header := c.Request.Header.Get(Range)
+	if header == "" || cfg.NoRanges || c.GetBool(rangeIgnoredKey) || c.Writer.Header().Get(AcceptRanges) == "none" {
+		return nil, nil
+	}
+
+	// Ranges refer to the encoded representation. Unless content already
+	// is that, a compressing middleware would produce corrupt parts.
+	if !cfg.EncodedContent && encodingActive(c.Writer.Header()) {
+		return nil, nil
+	}
+
+	ranges, err := ParseRange(header, size)
+	if errors.Is(err, ErrRangeNotSatisfiable) {
+		return nil, err
+	}
+
+	ranges = coalesceRanges(ranges)
+	if cfg.MaxRanges > 0 && len(ranges) > cfg.MaxRanges {
+		if cfg.RejectExcessRanges {
+			return nil, wrapError(ErrRangeNotSatisfiable, Range)
+		}
+		return nil, nil
+	}
+	return ranges, nil
+}
+
+// Reports whether a Content-Encoding transform applies to the response.
+func encodingActive(header http.Header) bool {
+	encoding := header.Get("Content-Encoding")
+	return encoding != "" && !strings.EqualFold(encoding, "identity")
+}
+
+// Merges overlapping and adjacent ranges, returning them in ascending
+// order, so a request cannot make the same bytes be read repeatedly.
+func coalesceRanges(ranges []ByteRange) []ByteRange {
+	if len(ranges) < 2 {
+		return ranges
+	}
+
+	sorted := append([]ByteRange(nil), ranges...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Start < sorted[j].Start })
+
+	merged := sorted[:1]
+	for _, r := range sorted[1:] {
+		last := &merged[len(merged)-1]
+		if end := last.Start + last.Length; r.Start <= end {
+			last.Length = max(end, r.Start+r.Length) - last.Start
+			continue
+		}
+		merged = append(merged, r)
+	}
+	return merged
+}
+
+// HEAD responses carry the headers only.
+func (cfg *Config) writeBody(c *gin.Context, content io.Reader, n int64) {
+	c.Writer.WriteHeaderNow()
+	if c.Request.Method == Head {
+		return
+	}
+	if f, ok := content.(*os.File); ok && cfg.Sendfile && cfg.ChunkSize <= 0 {
+		if w, ok := readerFrom(c.Writer); ok {
+			w.ReadFrom(io.LimitReader(f, n))
+			return
+		}
+	}
+	cfg.copyBody(c, c.Writer, content, n)
+}
+
+// Unwraps w down to a writer that can take a file directly, such as
+// net/http's, which uses sendfile for it.
+func readerFrom(w http.ResponseWriter) (io.ReaderFrom, bool) {
+	for {
+		if rf, ok := w.(io.ReaderFrom); ok {
+			return rf, true
+		}
+		u, ok := w.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			return nil, false
+		}
+		w = u.Unwrap()
+	}
+}
+
+// Copies n bytes of content to w. With a ChunkSize, the body is streamed
+// chunk by chunk, flushing each, and the copy stops as soon as the request
+// context is cancelled so the reader is released early.
+func (cfg *Config) copyBody(c *gin.Context, w io.Writer, content io.Reader, n int64) error {
+	if cfg.ChunkSize <= 0 {
+		_, err := io.CopyN(w, content, n)
+		return err
+	}
+
+	ctx := c.Request.Context()
+	buf := make([]byte, min(int64(cfg.ChunkSize), n))
+	for n > 0 {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		chunk := buf[:min(int64(len(buf)), n)]
+		read, err := io.ReadFull(content, chunk)
+		if read > 0 {
+			if _, werr := w.Write(chunk[:read]); werr != nil {
+				return werr
+			}
+			c.Writer.Flush()
+			n -= int64(read)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RangeReadable can be implemented by resources that can hand out their
+// content, so the package serves Range and If-Range requests itself. The
+// reader is closed after use when it is an io.Closer.
+type RangeReadable interface {
+	Content() (io.ReadSeeker, int64, error)
+}
+
+// ReaderAtReadable is the random access counterpart of RangeReadable, for
+// very large resources. Each request reads through its own
+// io.SectionReader, so concurrent ranged requests share one io.ReaderAt,
+// such as an *os.File, without seeking it or opening a handle each.
+type ReaderAtReadable interface {
+	ContentAt() (io.ReaderAt, int64, error)
+}
+
+// Returns the content of a RangeReadable or ReaderAtReadable resource.
+func openContent(resource interface{}) (io.ReadSeeker, int64, error) {
+	switch r := resource.(type) {
+	case RangeReadable:
+		return r.Content()
+	case ReaderAtReadable:
+		at, size, err := r.ContentAt()
+		if err != nil {
+			return nil, 0, err
+		}
+		return io.NewSectionReader(at, 0, size), size, nil
+	}
+	return nil, 0, ErrNoResource
+}
+
+// RangeAdvertiser can be implemented by resources to declare whether they
+// support range requests, advertised with Accept-Ranges.
+type RangeAdvertiser interface {
+	AcceptsRanges() bool
+}
+
+// AdvertiseRanges declares range support for a route or group, emitting
+// "Accept-Ranges: bytes" or "Accept-Ranges: none" on its responses. With
+// none, ServeRange sends full responses only.
+func AdvertiseRanges(supported bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		setAcceptRanges(c.Writer.Header(), supported)
+		c.Next()
+	}
+}
+
+// Sets Accept-Ranges from what resource declares, unless the route already
+// did, see AdvertiseRanges. RangeReadable and ReaderAtReadable resources
+// support bytes ranges.
+func (cfg *Config) advertiseRanges(header http.Header, resource interface{}) {
+	if header.Get(AcceptRanges) != "" {
+		return
+	}
+	if cfg.NoRanges {
+		setAcceptRanges(header, false)
+		return
+	}
+	switch r := resource.(type) {
+	case RangeAdvertiser:
+		setAcceptRanges(header, r.AcceptsRanges())
+	case RangeReadable, ReaderAtReadable:
+		setAcceptRanges(header, true)
+	}
+}
+
+func setAcceptRanges(header http.Header, supported bool) {
+	if supported {
+		header.Set(AcceptRanges, "bytes")
+	} else {
+		header.Set(AcceptRanges, "none")
+	}
+}
+
+// Serve evaluates the request's preconditions against resource and, when
+// they pass, writes its content honouring Range. The resource implements
+// RangeReadable or ReaderAtReadable.
+func Serve(c *gin.Context, resource interface{}) {
+	ConfigOf(c).Serve(c, resource)
+}
+
+func (cfg *Config) Serve(c *gin.Context, resource interface{}) {
+	handled, err := cfg.Conditional(c, resource)
+	if handled {
+		return
+	}
+	if status := errorStatus(err); status != 0 {
+		c.AbortWithStatus(status)
+		return
+	}
+
+	content, size, err := openContent(resource)
+	if errors.Is(err, ErrNoResource) {
+		c.AbortWithStatus(http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	if closer, ok := content.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	cfg.ServeRange(c, content, size)
+}
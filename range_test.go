@@ -0,0 +1,249 @@
+package conditional
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func serveRouter(cfg *Config) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	resource := BytesResource([]byte("0123456789"), `"a"`, time.Unix(1e9, 0))
+	serve := func(c *gin.Context) {
+		Serve(c, resource)
+	}
+	r.GET("/", Policy{Config: cfg}.Handler(), serve)
+	r.HEAD("/", Policy{Config: cfg}.Handler(), serve)
+	return r
+}
+
+func TestServeOmitValidators(t *testing.T) {
+	for _, header := range []http.Header{
+		{},
+		{Range: {"bytes=0-1"}},
+		{Range: {"bytes=0-1"}, IfRange: {`"b"`}},
+		{IfNoneMatch: {`"a"`}},
+	} {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(Get, "/", nil)
+		req.Header = header
+		serveRouter(&Config{OmitValidators: true}).ServeHTTP(w, req)
+
+		if etag, lm := w.Header().Get(ETag), w.Header().Get(LastModified); etag != "" || lm != "" {
+			t.Errorf("%v: %d with ETag %q, Last-Modified %q, want neither", header, w.Code, etag, lm)
+		}
+	}
+}
+
+func TestServeValidators(t *testing.T) {
+	w := httptest.NewRecorder()
+	serveRouter(&Config{}).ServeHTTP(w, httptest.NewRequest(Head, "/", nil))
+	if etag := w.Header().Get(ETag); etag != `"a"` {
+		t.Errorf("ETag = %q, want \"a\"", etag)
+	}
+}
+
+func TestServeRange(t *testing.T) {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(Get, "/", nil)
+	req.Header.Set(Range, "bytes=2-4")
+	serveRouter(&Config{}).ServeHTTP(w, req)
+
+	if w.Code != http.StatusPartialContent || w.Body.String() != "234" {
+		t.Errorf("got %d %q, want 206 \"234\"", w.Code, w.Body.String())
+	}
+	if cr := w.Header().Get("Content-Range"); cr != "bytes 2-4/10" {
+		t.Errorf("Content-Range = %q", cr)
+	}
+}
+
+type advertisedBytes struct {
+	*Bytes
+	ranges bool
+}
+
+func (b advertisedBytes) AcceptsRanges() bool {
+	return b.ranges
+}
+
+func TestAdvertiseRangesRouteWins(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	resource := advertisedBytes{BytesResource([]byte("0123456789"), `"a"`, time.Unix(1e9, 0)), true}
+	r.GET("/none", AdvertiseRanges(false), func(c *gin.Context) { Serve(c, resource) })
+	r.GET("/default", func(c *gin.Context) { Serve(c, resource) })
+
+	for path, want := range map[string]struct {
+		status int
+		ranges string
+	}{
+		"/none":    {http.StatusOK, "none"},
+		"/default": {http.StatusPartialContent, "bytes"},
+	} {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(Get, path, nil)
+		req.Header.Set(Range, "bytes=0-1")
+		r.ServeHTTP(w, req)
+		if w.Code != want.status || w.Header().Get(AcceptRanges) != want.ranges {
+			t.Errorf("%s: %d with Accept-Ranges %q, want %d with %q",
+				path, w.Code, w.Header().Get(AcceptRanges), want.status, want.ranges)
+		}
+	}
+}
+
+// readFromRecorder records the files handed to ReadFrom, as net/http's
+// response hands them to sendfile.
+type readFromRecorder struct {
+	*httptest.ResponseRecorder
+	readFrom int
+}
+
+func (w *readFromRecorder) ReadFrom(r io.Reader) (int64, error) {
+	w.readFrom++
+	return io.Copy(w.ResponseRecorder, r)
+}
+
+func TestSendfile(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "video.mp4"), []byte("0123456789"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		cfg      *Config
+		rangeHdr string
+		readFrom int
+		body     string
+	}{
+		{"sendfile", &Config{Sendfile: true}, "", 1, "0123456789"},
+		{"sendfile range", &Config{Sendfile: true}, "bytes=2-4", 1, "234"},
+		{"without sendfile", &Config{}, "", 0, "0123456789"},
+		{"chunked", &Config{Sendfile: true, ChunkSize: 4}, "", 0, "0123456789"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			tt.cfg.Static(r, "/media", root)
+
+			w := &readFromRecorder{ResponseRecorder: httptest.NewRecorder()}
+			req := httptest.NewRequest(Get, "/media/video.mp4", nil)
+			if tt.rangeHdr != "" {
+				req.Header.Set(Range, tt.rangeHdr)
+			}
+			r.ServeHTTP(w, req)
+
+			if w.readFrom != tt.readFrom || w.Body.String() != tt.body {
+				t.Errorf("got %q through %d ReadFrom calls, want %q through %d", w.Body, w.readFrom, tt.body, tt.readFrom)
+			}
+		})
+	}
+}
+
+func TestServeMultipart(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	serve := func(c *gin.Context) {
+		c.Header("Content-Type", "text/plain")
+		ServeRange(c, strings.NewReader("0123456789"), 10)
+	}
+	r.GET("/", serve)
+	r.HEAD("/", serve)
+
+	type part struct{ contentRange, body string }
+	tests := []struct {
+		name   string
+		ranges string
+		parts  []part
+	}{
+		{"two ranges", "bytes=0-1,5-6", []part{{"bytes 0-1/10", "01"}, {"bytes 5-6/10", "56"}}},
+		{"suffix range", "bytes=0-0,-2", []part{{"bytes 0-0/10", "0"}, {"bytes 8-9/10", "89"}}},
+		{"sorted", "bytes=7-8,1-2", []part{{"bytes 1-2/10", "12"}, {"bytes 7-8/10", "78"}}},
+		{"three ranges", "bytes=0-0,3-3,9-", []part{{"bytes 0-0/10", "0"}, {"bytes 3-3/10", "3"}, {"bytes 9-9/10", "9"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := request(r, Get, "/", map[string]string{Range: tt.ranges})
+			mediaType, params, err := mime.ParseMediaType(w.Header().Get("Content-Type"))
+			if w.Code != http.StatusPartialContent || err != nil || mediaType != "multipart/byteranges" {
+				t.Fatalf("got %d with Content-Type %q", w.Code, w.Header().Get("Content-Type"))
+			}
+			if got := w.Header().Get("Content-Length"); got != strconv.Itoa(w.Body.Len()) {
+				t.Errorf("Content-Length = %s, body is %d bytes", got, w.Body.Len())
+			}
+
+			mr := multipart.NewReader(w.Body, params["boundary"])
+			for i, want := range tt.parts {
+				p, err := mr.NextPart()
+				if err != nil {
+					t.Fatalf("part %d: %v", i, err)
+				}
+				body, _ := io.ReadAll(p)
+				if got := p.Header.Get("Content-Range"); got != want.contentRange || string(body) != want.body {
+					t.Errorf("part %d: %s %q, want %s %q", i, got, body, want.contentRange, want.body)
+				}
+				if ct := p.Header.Get("Content-Type"); ct != "text/plain" {
+					t.Errorf("part %d: Content-Type %q", i, ct)
+				}
+			}
+			if _, err := mr.NextPart(); err != io.EOF {
+				t.Errorf("after the parts: %v, want io.EOF", err)
+			}
+
+			head := request(r, Head, "/", map[string]string{Range: tt.ranges})
+			if head.Header().Get("Content-Length") != w.Header().Get("Content-Length") || head.Body.Len() != 0 {
+				t.Errorf("HEAD: Content-Length %s with %d body bytes", head.Header().Get("Content-Length"), head.Body.Len())
+			}
+		})
+	}
+
+	// Overlapping ranges coalesce into a single part response.
+	w := request(r, Get, "/", map[string]string{Range: "bytes=0-4,3-6"})
+	if w.Code != http.StatusPartialContent || w.Body.String() != "0123456" || w.Header().Get("Content-Range") != "bytes 0-6/10" {
+		t.Errorf("coalesced: got %d %q, Content-Range %q", w.Code, w.Body, w.Header().Get("Content-Range"))
+	}
+}
+
+func TestMultipartLength(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers []textproto.MIMEHeader
+		ranges  []ByteRange
+	}{
+		{"empty", nil, nil},
+		{"one part", []textproto.MIMEHeader{{"Content-Range": {"bytes 0-9/100"}}}, []ByteRange{{0, 10}}},
+		{"typed parts", []textproto.MIMEHeader{
+			{"Content-Range": {"bytes 0-9/100"}, "Content-Type": {"text/plain"}},
+			{"Content-Range": {"bytes 50-99/100"}, "Content-Type": {"text/plain"}},
+		}, []ByteRange{{0, 10}, {50, 50}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			mw := multipart.NewWriter(&buf)
+			for i, r := range tt.ranges {
+				p, _ := mw.CreatePart(tt.headers[i])
+				p.Write(make([]byte, r.Length))
+			}
+			mw.Close()
+
+			if got := multipartLength(mw.Boundary(), tt.headers, tt.ranges); got != int64(buf.Len()) {
+				t.Errorf("got %d, written %d", got, buf.Len())
+			}
+		})
+	}
+}
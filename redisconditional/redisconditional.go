@@ -0,0 +1,132 @@
+// Shares gin-conditional validators between instances through Redis.
+package redisconditional
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	conditional "github.com/itsjamie/gin-conditional"
+	"github.com/redis/go-redis/v9"
+)
+
+// Store is a conditional.ValidatorStore keeping validators as JSON under
+// Prefix+key. With a Channel, invalidated keys are also published, so
+// instances can drop them from in-process caches, see Subscribe:
+//
+//	store := &redisconditional.Store{Client: rdb, Prefix: "validators:", TTL: time.Hour}
+//	cfg.Invalidators = append(cfg.Invalidators, conditional.StoreInvalidator(store, nil))
+type Store struct {
+	Client redis.UniversalClient
+	Prefix string
+
+	// Expiry of stored validators, none when zero.
+	TTL time.Duration
+
+	// Pub/Sub channel invalidated keys are published to, none when empty.
+	Channel string
+}
+
+func (s *Store) Get(ctx context.Context, key string) (conditional.Validators, bool, error) {
+	var v conditional.Validators
+	data, err := s.Client.Get(ctx, s.Prefix+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return v, false, nil
+	}
+	if err != nil {
+		return v, false, err
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return v, false, err
+	}
+	return v, true, nil
+}
+
+func (s *Store) Set(ctx context.Context, key string, v conditional.Validators) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return s.Client.Set(ctx, s.Prefix+key, data, s.TTL).Err()
+}
+
+// GetValidators implements conditional.BatchValidatorStore with MGET.
+func (s *Store) GetValidators(ctx context.Context, keys []string) (map[string]conditional.Validators, error) {
+	if len(keys) == 0 {
+		return map[string]conditional.Validators{}, nil
+	}
+	names := make([]string, len(keys))
+	for i, key := range keys {
+		names[i] = s.Prefix + key
+	}
+	values, err := s.Client.MGet(ctx, names...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	found := make(map[string]conditional.Validators, len(keys))
+	for i, value := range values {
+		data, ok := value.(string)
+		if !ok {
+			continue
+		}
+		var v conditional.Validators
+		if err := json.Unmarshal([]byte(data), &v); err != nil {
+			return nil, err
+		}
+		found[keys[i]] = v
+	}
+	return found, nil
+}
+
+// SetValidators implements conditional.BatchValidatorStore, pipelining
+// one SET per key.
+func (s *Store) SetValidators(ctx context.Context, validators map[string]conditional.Validators) error {
+	_, err := s.Client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for key, v := range validators {
+			data, err := json.Marshal(v)
+			if err != nil {
+				return err
+			}
+			pipe.Set(ctx, s.Prefix+key, data, s.TTL)
+		}
+		return nil
+	})
+	return err
+}
+
+func (s *Store) Invalidate(ctx context.Context, key string) error {
+	if err := s.Client.Del(ctx, s.Prefix+key).Err(); err != nil {
+		return err
+	}
+	if s.Channel != "" {
+		return s.Client.Publish(ctx, s.Channel, key).Err()
+	}
+	return nil
+}
+
+// Subscribe tells inv about every key invalidated through the Channel,
+// by any instance, until ctx is done:
+//
+//	go store.Subscribe(ctx, cache)
+func (s *Store) Subscribe(ctx context.Context, inv conditional.Invalidator) error {
+	sub := s.Client.Subscribe(ctx, s.Channel)
+	defer sub.Close()
+
+	if _, err := sub.Receive(ctx); err != nil {
+		return err
+	}
+	messages := sub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case msg, ok := <-messages:
+			if !ok {
+				return nil
+			}
+			inv.OnResourceChanged(msg.Payload)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package redisconditional
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	conditional "github.com/itsjamie/gin-conditional"
+	"github.com/redis/go-redis/v9"
+)
+
+func newStore(t *testing.T) (*Store, *miniredis.Miniredis) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return &Store{Client: client, Prefix: "validators:", TTL: time.Hour, Channel: "invalidated"}, mr
+}
+
+func TestStore(t *testing.T) {
+	ctx := context.Background()
+	s, mr := newStore(t)
+	v := conditional.Validators{ETag: `"a"`, LastModified: time.Unix(1e9, 0).UTC()}
+
+	if _, ok, err := s.Get(ctx, "/x"); ok || err != nil {
+		t.Fatalf("empty store: found %v, %v", ok, err)
+	}
+	if err := s.Set(ctx, "/x", v); err != nil {
+		t.Fatal(err)
+	}
+	got, ok, err := s.Get(ctx, "/x")
+	if !ok || err != nil || got.ETag != v.ETag || !got.LastModified.Equal(v.LastModified) {
+		t.Errorf("got %+v, %v, %v, want %+v", got, ok, err, v)
+	}
+	if !mr.Exists("validators:/x") {
+		t.Error("key not stored under the prefix")
+	}
+	if ttl := mr.TTL("validators:/x"); ttl != time.Hour {
+		t.Errorf("TTL = %v, want 1h", ttl)
+	}
+
+	mr.Set("validators:/bad", "{")
+	if _, _, err := s.Get(ctx, "/bad"); err == nil {
+		t.Error("malformed JSON: no error")
+	}
+}
+
+func TestStoreBatch(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newStore(t)
+
+	if found, err := s.GetValidators(ctx, nil); len(found) != 0 || err != nil {
+		t.Errorf("no keys: %v, %v", found, err)
+	}
+
+	err := s.SetValidators(ctx, map[string]conditional.Validators{
+		"/a": {ETag: `"a"`},
+		"/b": {ETag: `"b"`},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	found, err := s.GetValidators(ctx, []string{"/a", "/missing", "/b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) != 2 || found["/a"].ETag != `"a"` || found["/b"].ETag != `"b"` {
+		t.Errorf("got %v, want /a and /b", found)
+	}
+}
+
+func TestStoreInvalidate(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s, mr := newStore(t)
+	if err := s.Set(ctx, "/x", conditional.Validators{ETag: `"a"`}); err != nil {
+		t.Fatal(err)
+	}
+
+	changed := make(chan string, 1)
+	done := make(chan error, 1)
+	go func() {
+		done <- s.Subscribe(ctx, conditional.InvalidatorFunc(func(key string) { changed <- key }))
+	}()
+	// Invalidations published before the subscription would be lost.
+	for deadline := time.Now().Add(time.Second); len(mr.PubSubChannels("")) == 0; {
+		if time.Now().After(deadline) {
+			t.Fatal("Subscribe did not subscribe")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	if err := s.Invalidate(ctx, "/x"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, _ := s.Get(ctx, "/x"); ok {
+		t.Error("invalidated key still stored")
+	}
+	select {
+	case key := <-changed:
+		if key != "/x" {
+			t.Errorf("subscriber told about %q, want /x", key)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("subscriber not told about the invalidation")
+	}
+
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("Subscribe returned %v, want context.Canceled", err)
+	}
+}
+
+func TestStoreWithoutChannel(t *testing.T) {
+	ctx := context.Background()
+	s, mr := newStore(t)
+	s.Channel = ""
+	s.Set(ctx, "/x", conditional.Validators{ETag: `"a"`})
+
+	if err := s.Invalidate(ctx, "/x"); err != nil {
+		t.Fatal(err)
+	}
+	if mr.Exists("validators:/x") {
+		t.Error("invalidated key still stored")
+	}
+}
+
+func TestStoreErrors(t *testing.T) {
+	ctx := context.Background()
+	s, mr := newStore(t)
+	s.Client = redis.NewClient(&redis.Options{Addr: mr.Addr(), MaxRetries: -1})
+	defer s.Client.Close()
+	mr.Close()
+
+	if _, _, err := s.Get(ctx, "/x"); err == nil {
+		t.Error("Get: no error")
+	}
+	if err := s.Set(ctx, "/x", conditional.Validators{}); err == nil {
+		t.Error("Set: no error")
+	}
+	if err := s.Invalidate(ctx, "/x"); err == nil {
+		t.Error("Invalidate: no error")
+	}
+}
@@ -0,0 +1,70 @@
+package conditional
+
+import (
+	"context"
+	"time"
+)
+
+// RefreshAhead recomputes, every interval, the validators of the keys in
+// vc that would expire before the next pass, so frequently revalidated
+// resources keep hitting the cache instead of waiting on compute when
+// their entry expires. It needs a TTL to do anything. Refreshing stops
+// when cfg is closed, and Close waits for a pass in progress.
+func (vc *ValidatorCache) RefreshAhead(cfg *Config, interval time.Duration, compute func(key string) (Validators, error)) {
+	if cfg == nil {
+		cfg = DefaultConfig
+	}
+	stopped := make(chan struct{})
+	go vc.refresh(cfg, interval, compute, stopped)
+	cfg.OnClose(func(ctx context.Context) error {
+		select {
+		case <-stopped:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+}
+
+func (vc *ValidatorCache) refresh(cfg *Config, interval time.Duration, compute func(key string) (Validators, error), stopped chan struct{}) {
+	defer close(stopped)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-cfg.Done():
+			return
+		case <-ticker.C:
+		}
+
+		for _, key := range vc.expiring(vc.now().Add(interval)) {
+			select {
+			case <-cfg.Done():
+				return
+			default:
+			}
+			if v, err := compute(key); err == nil {
+				vc.Set(key, v)
+			} else {
+				cfg.logf("conditional: refreshing %s: %v", key, err)
+			}
+		}
+	}
+}
+
+// Returns the keys whose entries expire before deadline.
+func (vc *ValidatorCache) expiring(deadline time.Time) []string {
+	var keys []string
+	for i := range vc.shards {
+		s := &vc.shards[i]
+		s.mu.Lock()
+		for key, entry := range s.entries {
+			if !entry.expires.IsZero() && entry.expires.Before(deadline) {
+				keys = append(keys, key)
+			}
+		}
+		s.mu.Unlock()
+	}
+	return keys
+}
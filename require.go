@@ -0,0 +1,35 @@
+package conditional
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequirePreconditions rejects PUT, PATCH and DELETE requests that carry
+// neither If-Match nor If-Unmodified-Since with 428 (Precondition Required),
+// forcing clients to prove which state they are about to change.
+//
+// Implements Section 3 from RFC6585
+// https://tools.ietf.org/html/rfc6585#section-3
+func RequirePreconditions() gin.HandlerFunc {
+	return DefaultConfig.RequirePreconditions()
+}
+
+func (cfg *Config) RequirePreconditions() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case Put, Patch, Delete:
+		default:
+			c.Next()
+			return
+		}
+
+		if cfg.header(c.Request, IfMatch) == "" && cfg.header(c.Request, IfUnmodifiedSince) == "" {
+			c.AbortWithStatus(http.StatusPreconditionRequired)
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,42 @@
+package conditional
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequirePreconditions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	aliased := &Config{Aliases: []HeaderAlias{{Request: "X-If-Match", Standard: IfMatch}}}
+
+	tests := []struct {
+		name   string
+		cfg    *Config
+		method string
+		header map[string]string
+		status int
+	}{
+		{"put without preconditions", &Config{}, Put, nil, http.StatusPreconditionRequired},
+		{"patch without preconditions", &Config{}, Patch, nil, http.StatusPreconditionRequired},
+		{"delete without preconditions", &Config{}, Delete, nil, http.StatusPreconditionRequired},
+		{"put with if-match", &Config{}, Put, map[string]string{IfMatch: `"a"`}, http.StatusNoContent},
+		{"put with if-unmodified-since", &Config{}, Put, map[string]string{IfUnmodifiedSince: "Sun, 09 Sep 2001 01:46:40 GMT"}, http.StatusNoContent},
+		{"put with if-none-match only", &Config{}, Put, map[string]string{IfNoneMatch: "*"}, http.StatusPreconditionRequired},
+		{"put with an alias", aliased, Put, map[string]string{"X-If-Match": `"a"`}, http.StatusNoContent},
+		{"get", &Config{}, Get, nil, http.StatusNoContent},
+		{"post", &Config{}, http.MethodPost, nil, http.StatusNoContent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.Use(tt.cfg.RequirePreconditions())
+			r.NoRoute(func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+			if w := request(r, tt.method, "/", tt.header); w.Code != tt.status {
+				t.Errorf("got %d, want %d", w.Code, tt.status)
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package conditional
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ServeResumable serves a download with correct resume semantics: a strong
+// ETag, If-Range validation and 206 for the requested ranges. The
+// resource's own ETag is used when it is strong, otherwise one is computed
+// from the content, since If-Range can only match strong validators. The
+// resource implements RangeReadable or ReaderAtReadable.
+func ServeResumable(c *gin.Context, resource interface{}) {
+	ConfigOf(c).ServeResumable(c, resource)
+}
+
+func (cfg *Config) ServeResumable(c *gin.Context, resource interface{}) {
+	var v Validators
+	if e, ok := resource.(Etagger); ok {
+		etag, err := e.Etag()
+		if errors.Is(err, ErrNoResource) {
+			c.AbortWithStatus(http.StatusNotFound)
+			return
+		}
+		if err == nil && !isWeak(etag) {
+			v.ETag = etag
+		}
+	}
+	if m, ok := resource.(LastModifier); ok {
+		v.LastModified = m.LastModified()
+	}
+
+	content, _, err := openContent(resource)
+	if errors.Is(err, ErrNoResource) {
+		c.AbortWithStatus(http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	if closer, ok := content.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	if v.ETag == "" {
+		if v.ETag, err = cfg.hashEtagReader(content); err != nil {
+			c.AbortWithError(http.StatusInternalServerError, err)
+			return
+		}
+	}
+
+	cfg.ServeContentConditional(c, v, content)
+}
@@ -0,0 +1,69 @@
+package conditional
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// readerAtDownload is a ReaderAtReadable without validators.
+type readerAtDownload []byte
+
+func (d readerAtDownload) ContentAt() (io.ReaderAt, int64, error) {
+	return bytes.NewReader(d), int64(len(d)), nil
+}
+
+// goneDownload reports that the download no longer exists.
+type goneDownload struct{}
+
+func (goneDownload) Etag() (string, error) {
+	return "", ErrNoResource
+}
+
+func (goneDownload) Content() (io.ReadSeeker, int64, error) {
+	return nil, 0, ErrNoResource
+}
+
+func TestServeResumable(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	data := []byte("0123456789")
+	modified := time.Date(2001, 9, 9, 1, 46, 40, 0, time.UTC)
+	computed := (&Config{}).EtagFromBytes(data)
+
+	tests := []struct {
+		name     string
+		resource interface{}
+		header   map[string]string
+		status   int
+		body     string
+		etag     string
+	}{
+		{"strong etag", BytesResource(data, `"v1"`, modified), nil, http.StatusOK, "0123456789", `"v1"`},
+		{"resume", BytesResource(data, `"v1"`, modified), map[string]string{Range: "bytes=4-", IfRange: `"v1"`}, http.StatusPartialContent, "456789", `"v1"`},
+		{"changed since", BytesResource(data, `"v1"`, modified), map[string]string{Range: "bytes=4-", IfRange: `"v0"`}, http.StatusOK, "0123456789", `"v1"`},
+		{"weak etag replaced", BytesResource(data, `W/"v1"`, modified), nil, http.StatusOK, "0123456789", computed},
+		{"resume weak", BytesResource(data, `W/"v1"`, modified), map[string]string{Range: "bytes=4-", IfRange: computed}, http.StatusPartialContent, "456789", computed},
+		{"reader at", readerAtDownload(data), map[string]string{Range: "bytes=0-1", IfRange: computed}, http.StatusPartialContent, "01", computed},
+		{"revalidated", readerAtDownload(data), map[string]string{IfNoneMatch: computed}, http.StatusNotModified, "", computed},
+		{"gone", goneDownload{}, nil, http.StatusNotFound, "", ""},
+		{"not downloadable", struct{}{}, nil, http.StatusNotFound, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.GET("/", func(c *gin.Context) { ServeResumable(c, tt.resource) })
+
+			w := request(r, Get, "/", tt.header)
+			if w.Code != tt.status || w.Body.String() != tt.body {
+				t.Errorf("got %d %q, want %d %q", w.Code, w.Body, tt.status, tt.body)
+			}
+			if got := w.Header().Get(ETag); got != tt.etag {
+				t.Errorf("ETag = %q, want %q", got, tt.etag)
+			}
+		})
+	}
+}
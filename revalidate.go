@@ -0,0 +1,55 @@
+package conditional
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// Revalidated can be implemented by consistency-critical resources, such
+// as balances or inventory, whose cached copies must never be used
+// without asking this server first. Whenever their validators are set, the
+// Cache-Control header is made to include no-cache, or must-revalidate
+// next to an explicit freshness lifetime, and directives allowing stale
+// use are dropped.
+type Revalidated interface {
+	MustRevalidate() bool
+}
+
+// Directives letting caches use a stale response without revalidating.
+var staleDirectives = []string{"stale-while-revalidate", "stale-if-error"}
+
+func enforceRevalidation(header http.Header, resource interface{}) {
+	if r, ok := resource.(Revalidated); !ok || !r.MustRevalidate() {
+		return
+	}
+
+	var directives []string
+	fresh, revalidates := false, false
+	for _, value := range header.Values("Cache-Control") {
+		for _, directive := range strings.Split(value, ",") {
+			directive = strings.TrimSpace(directive)
+			name, _, _ := strings.Cut(strings.ToLower(directive), "=")
+			switch {
+			case name == "":
+				continue
+			case name == "no-cache", name == "no-store", name == "must-revalidate":
+				revalidates = true
+			case name == "max-age", name == "s-maxage":
+				fresh = true
+			case slices.Contains(staleDirectives, name):
+				continue
+			}
+			directives = append(directives, directive)
+		}
+	}
+
+	if !revalidates {
+		if fresh {
+			directives = append(directives, "must-revalidate")
+		} else {
+			directives = append(directives, "no-cache")
+		}
+	}
+	header.Set("Cache-Control", strings.Join(directives, ", "))
+}
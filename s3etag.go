@@ -0,0 +1,49 @@
+package conditional
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"io"
+	"strconv"
+)
+
+// S3MultipartEtag computes the ETag S3 assigns to an object uploaded in
+// parts of partSize bytes: the MD5 of the concatenated part MD5s followed
+// by the part count, "<md5-of-md5s>-<N>". Services fronting a bucket can
+// then validate If-Match and If-None-Match against S3's own ETags.
+func S3MultipartEtag(r io.Reader, partSize int64) (string, error) {
+	if partSize <= 0 {
+		return "", errors.New("conditional: S3 part size must be positive")
+	}
+
+	var sums []byte
+	parts := 0
+	for {
+		h := md5.New()
+		n, err := io.CopyN(h, r, partSize)
+		if n > 0 || parts == 0 {
+			sums = h.Sum(sums)
+			parts++
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+
+	sum := md5.Sum(sums)
+	return `"` + hex.EncodeToString(sum[:]) + "-" + strconv.Itoa(parts) + `"`, nil
+}
+
+// S3Etag computes the ETag S3 assigns to an object uploaded in a single
+// request, the hex MD5 of its content.
+func S3Etag(r io.Reader) (string, error) {
+	h := md5.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return "", err
+	}
+	return `"` + hex.EncodeToString(h.Sum(nil)) + `"`, nil
+}
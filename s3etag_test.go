@@ -0,0 +1,52 @@
+package conditional
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestS3MultipartEtag(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		partSize int64
+		want     string
+	}{
+		{"three parts", "0123456789", 4, `"61e3716e3a7767581863b67c4e785584-3"`},
+		{"exact parts", "0123456789", 5, `"9a6dbec798b1bfe66cc7659d2bb41720-2"`},
+		{"one exact part", "0123456789", 10, `"8e938564cd1410f0ec1c1781466a6738-1"`},
+		{"one short part", "0123456789", 100, `"8e938564cd1410f0ec1c1781466a6738-1"`},
+		{"empty", "", 4, `"59adb24ef3cdbe0297f05b395827453f-1"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := S3MultipartEtag(strings.NewReader(tt.data), tt.partSize)
+			if got != tt.want || err != nil {
+				t.Errorf("got %s, %v, want %s", got, err, tt.want)
+			}
+		})
+	}
+
+	if _, err := S3MultipartEtag(strings.NewReader("x"), 0); err == nil {
+		t.Error("no error for a zero part size")
+	}
+	errRead := errors.New("read failed")
+	if _, err := S3MultipartEtag(iotest.ErrReader(errRead), 4); !errors.Is(err, errRead) {
+		t.Errorf("got %v, want the read error", err)
+	}
+}
+
+func TestS3Etag(t *testing.T) {
+	for _, tt := range []struct {
+		data, want string
+	}{
+		{"", `"d41d8cd98f00b204e9800998ecf8427e"`},
+		{"0123456789", `"781e5e245d69b566979b86e28d23f2c7"`},
+	} {
+		if got, err := S3Etag(strings.NewReader(tt.data)); got != tt.want || err != nil {
+			t.Errorf("S3Etag(%q) = %s, %v, want %s", tt.data, got, err, tt.want)
+		}
+	}
+}
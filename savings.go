@@ -0,0 +1,49 @@
+package conditional
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Sizer can be implemented by resources that know the length of their
+// full representation, so the bytes a 304 saved can be counted.
+type Sizer interface {
+	Size() int64
+}
+
+// SavingsObserver can be implemented by Observers to be told the body
+// bytes not sent because of a 304, or because a 206 sent only part of the
+// representation, such as to report cumulative savings.
+type SavingsObserver interface {
+	BytesSaved(c *gin.Context, n int64)
+}
+
+// Accounts the body a 304 replaces: the resource's Size, or else the
+// Content-Length the handler set for a full response.
+func (cfg *Config) notModifiedSaved(c *gin.Context, resource interface{}) {
+	if s, ok := resource.(Sizer); ok {
+		cfg.saved(c, s.Size())
+	} else if n, err := strconv.ParseInt(c.Writer.Header().Get("Content-Length"), 10, 64); err == nil {
+		cfg.saved(c, n)
+	}
+}
+
+// Adds n to the request's BytesSavedKey and tells the SavingsObservers.
+// HEAD responses have no body to save.
+func (cfg *Config) saved(c *gin.Context, n int64) {
+	if n <= 0 || c.Request.Method == http.MethodHead {
+		return
+	}
+
+	total, _ := c.Get(BytesSavedKey)
+	previous, _ := total.(int64)
+	c.Set(BytesSavedKey, previous+n)
+
+	for _, o := range cfg.Observers {
+		if s, ok := o.(SavingsObserver); ok {
+			s.BytesSaved(c, n)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package conditional
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ServeContentConditional is the counterpart of http.ServeContent: it
+// evaluates the request's preconditions against v, then writes content
+// with 200, 206 for ranges, 304 or 412 as the headers require. The
+// Content-Type is sniffed from content when the handler has not set one.
+func ServeContentConditional(c *gin.Context, v Validators, content io.ReadSeeker) {
+	ConfigOf(c).ServeContentConditional(c, v, content)
+}
+
+func (cfg *Config) ServeContentConditional(c *gin.Context, v Validators, content io.ReadSeeker) {
+	size, err := content.Seek(0, io.SeekEnd)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	resource := v.Resource()
+	if resource == nil {
+		// Without validators there is nothing to evaluate, but the
+		// content exists.
+		resource = contentOnly{}
+	}
+
+	handled, err := cfg.Conditional(c, resource)
+	if handled {
+		return
+	}
+	if status := errorStatus(err); status != 0 {
+		c.AbortWithStatus(status)
+		return
+	}
+
+	header := c.Writer.Header()
+	if header.Get("Content-Type") == "" {
+		if err := sniffContentType(header, content); err != nil {
+			c.AbortWithError(http.StatusInternalServerError, err)
+			return
+		}
+	}
+
+	cfg.ServeRange(c, content, size)
+}
+
+// Sets the Content-Type from the first bytes of content.
+func sniffContentType(header http.Header, content io.ReadSeeker) error {
+	var buf [512]byte
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	n, err := io.ReadFull(content, buf[:])
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return err
+	}
+	header.Set("Content-Type", http.DetectContentType(buf[:n]))
+	return nil
+}
+
+// contentOnly is a resource known to exist, with no validators.
+type contentOnly struct{}
+
+func (contentOnly) Exists() bool {
+	return true
+}
@@ -0,0 +1,53 @@
+package conditional
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func serveContent(cfg *Config, header http.Header) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	v := Validators{ETag: `"a"`, LastModified: time.Unix(1e9, 0)}
+	r.GET("/", Policy{Config: cfg}.Handler(), func(c *gin.Context) {
+		ServeContentConditional(c, v, strings.NewReader("<p>hello</p>"))
+	})
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(Get, "/", nil)
+	req.Header = header
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestServeContentConditional(t *testing.T) {
+	w := serveContent(&Config{}, http.Header{})
+	if w.Code != http.StatusOK || w.Body.String() != "<p>hello</p>" {
+		t.Fatalf("got %d %q", w.Code, w.Body.String())
+	}
+	if etag := w.Header().Get(ETag); etag != `"a"` {
+		t.Errorf("ETag = %q, want \"a\"", etag)
+	}
+	if lm := w.Header().Get(LastModified); lm != "Sun, 09 Sep 2001 01:46:40 GMT" {
+		t.Errorf("Last-Modified = %q", lm)
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/html") {
+		t.Errorf("Content-Type = %q, want sniffed text/html", ct)
+	}
+
+	if w := serveContent(&Config{}, http.Header{IfNoneMatch: {`"a"`}}); w.Code != http.StatusNotModified {
+		t.Errorf("If-None-Match: got %d, want 304", w.Code)
+	}
+}
+
+func TestServeContentOmitValidators(t *testing.T) {
+	w := serveContent(&Config{OmitValidators: true}, http.Header{})
+	if etag, lm := w.Header().Get(ETag), w.Header().Get(LastModified); etag != "" || lm != "" {
+		t.Errorf("ETag %q, Last-Modified %q, want neither", etag, lm)
+	}
+}
@@ -0,0 +1,120 @@
+package conditional
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// shutdown holds the hooks run by Config.Close, and tracks the background
+// work it waits for.
+type shutdown struct {
+	mu      sync.Mutex
+	hooks   []func(context.Context) error
+	running sync.WaitGroup
+	done    chan struct{}
+	closed  bool
+}
+
+func (s *shutdown) init() {
+	if s.done == nil {
+		s.done = make(chan struct{})
+	}
+}
+
+// OnClose registers a hook run by Close, for components with background
+// work such as write-back queues, refreshers or long-poll waiters. Hooks
+// run in reverse registration order. Registering after Close runs the
+// hook immediately with a background context.
+func (cfg *Config) OnClose(hook func(ctx context.Context) error) {
+	s := &cfg.shutdown
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		hook(context.Background())
+		return
+	}
+	s.hooks = append(s.hooks, hook)
+	s.mu.Unlock()
+}
+
+// Done returns a channel closed when Close starts, so waiters can respond
+// and let the server shut down.
+func (cfg *Config) Done() <-chan struct{} {
+	s := &cfg.shutdown
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.init()
+	return s.done
+}
+
+// Runs f in the background, Close waits for it. Once closed, f runs before
+// returning.
+func (cfg *Config) background(f func()) {
+	if !cfg.shutdown.enter() {
+		f()
+		return
+	}
+	go func() {
+		defer cfg.shutdown.exit()
+		f()
+	}()
+}
+
+// Counts work in progress that Close waits for, false once closed. Each
+// successful enter is paired with an exit.
+func (s *shutdown) enter() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return false
+	}
+	s.running.Add(1)
+	return true
+}
+
+func (s *shutdown) exit() {
+	s.running.Done()
+}
+
+// Close stops the runtime attached to cfg. Waiters on Done are released,
+// then every OnClose hook runs, and work in progress such as LongPoll
+// waiters and purges by AsyncInvalidators is waited for, until ctx
+// expires. It returns the
+// hooks' errors joined with the context's error if it expired.
+func (cfg *Config) Close(ctx context.Context) error {
+	s := &cfg.shutdown
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	s.init()
+	close(s.done)
+	hooks := s.hooks
+	s.hooks = nil
+	s.mu.Unlock()
+
+	var errs []error
+	for i := len(hooks) - 1; i >= 0; i-- {
+		if err := ctx.Err(); err != nil {
+			return errors.Join(append(errs, err)...)
+		}
+		if err := hooks[i](ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	idle := make(chan struct{})
+	go func() {
+		s.running.Wait()
+		close(idle)
+	}()
+	select {
+	case <-idle:
+	case <-ctx.Done():
+		errs = append(errs, ctx.Err())
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,250 @@
+package conditional
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type job struct {
+	mu   sync.Mutex
+	etag string
+}
+
+func (j *job) load() (interface{}, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return BytesResource(nil, j.etag, time.Time{}), nil
+}
+
+func longPollRouter(cfg *Config, j *job) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	cfg.Key = func(c *gin.Context) string { return "job" }
+	r := gin.New()
+	r.GET("/job", Policy{Config: cfg}.Handler(), func(c *gin.Context) {
+		if handled, _ := LongPoll(c, time.Minute, j.load); !handled {
+			c.Status(http.StatusOK)
+		}
+	})
+	return r
+}
+
+// Starts a request held by LongPoll, returning its recorder and a channel
+// closed once it was answered.
+func startWaiter(t *testing.T, cfg *Config, r *gin.Engine, etag string) (*httptest.ResponseRecorder, chan struct{}) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(Get, "/job", nil)
+	req.Header.Set(IfNoneMatch, etag)
+	answered := make(chan struct{})
+	go func() {
+		r.ServeHTTP(w, req)
+		close(answered)
+	}()
+
+	for deadline := time.Now().Add(time.Second); ; {
+		cfg.changes.mu.Lock()
+		waiting := len(cfg.changes.keys) > 0
+		cfg.changes.mu.Unlock()
+		if waiting {
+			return w, answered
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("request is not waiting")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestCloseDrainsLongPollWaiters(t *testing.T) {
+	cfg := &Config{}
+	r := longPollRouter(cfg, &job{etag: `"1"`})
+	w, answered := startWaiter(t, cfg, r, `"1"`)
+
+	if err := cfg.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-answered:
+	default:
+		t.Fatal("Close returned before the waiter was answered")
+	}
+	if w.Code != http.StatusNotModified {
+		t.Errorf("waiter got %d, want 304", w.Code)
+	}
+
+	// Once closed, requests are answered at once.
+	w = httptest.NewRecorder()
+	req := httptest.NewRequest(Get, "/job", nil)
+	req.Header.Set(IfNoneMatch, `"1"`)
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusNotModified {
+		t.Errorf("after Close got %d, want 304", w.Code)
+	}
+}
+
+func TestLongPollWakesOnChange(t *testing.T) {
+	cfg := &Config{}
+	j := &job{etag: `"1"`}
+	r := longPollRouter(cfg, j)
+	w, answered := startWaiter(t, cfg, r, `"1"`)
+
+	j.mu.Lock()
+	j.etag = `"2"`
+	j.mu.Unlock()
+	cfg.ResourceChanged("job")
+
+	select {
+	case <-answered:
+	case <-time.After(time.Second):
+		t.Fatal("waiter not woken by ResourceChanged")
+	}
+	if w.Code != http.StatusOK || w.Header().Get(ETag) != `"2"` {
+		t.Errorf("got %d with ETag %q, want 200 with \"2\"", w.Code, w.Header().Get(ETag))
+	}
+	if len(cfg.changes.keys) != 0 {
+		t.Errorf("%d keys still watched", len(cfg.changes.keys))
+	}
+}
+
+func TestLongPollStaleClient(t *testing.T) {
+	cfg := &Config{}
+	r := longPollRouter(cfg, &job{etag: `"2"`})
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(Get, "/job", nil)
+	req.Header.Set(IfNoneMatch, `"1"`)
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("got %d, want 200 without waiting", w.Code)
+	}
+}
+
+type recordingStore struct {
+	mu  sync.Mutex
+	set map[string]Validators
+}
+
+func (s *recordingStore) Get(ctx context.Context, key string) (Validators, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	v, ok := s.set[key]
+	return v, ok, nil
+}
+
+func (s *recordingStore) Set(ctx context.Context, key string, v Validators) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.set[key] = v
+	return nil
+}
+
+func (s *recordingStore) Invalidate(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.set, key)
+	return nil
+}
+
+func TestCloseFlushesWriteBackStore(t *testing.T) {
+	ctx := context.Background()
+	cfg := &Config{}
+	backing := &recordingStore{set: map[string]Validators{}}
+	store := NewWriteBackStore(cfg, backing, time.Hour)
+
+	store.Set(ctx, "a", Validators{ETag: `"a"`})
+	if v, ok, _ := store.Get(ctx, "a"); !ok || v.ETag != `"a"` {
+		t.Error("queued write not visible to Get")
+	}
+	if _, ok, _ := backing.Get(ctx, "a"); ok {
+		t.Fatal("write was not queued")
+	}
+
+	if err := cfg.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if v, ok, _ := backing.Get(ctx, "a"); !ok || v.ETag != `"a"` {
+		t.Error("Close did not flush the queue")
+	}
+}
+
+func TestWriteBackStoreBatches(t *testing.T) {
+	ctx := context.Background()
+	cfg := &Config{}
+	backing := NewValidatorCache(0, 0)
+	store := NewWriteBackStore(cfg, backing.Store(), 5*time.Millisecond)
+	defer cfg.Close(ctx)
+
+	store.Set(ctx, "a", Validators{ETag: `"a"`})
+	store.Set(ctx, "b", Validators{ETag: `"b"`})
+	for deadline := time.Now().Add(time.Second); ; time.Sleep(time.Millisecond) {
+		_, a := backing.Get("a")
+		_, b := backing.Get("b")
+		if a && b {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("queue not flushed in the background")
+		}
+	}
+
+	store.Invalidate(ctx, "a")
+	if _, ok, _ := store.Get(ctx, "a"); ok {
+		t.Error("invalidated key still found")
+	}
+}
+
+func TestCloseStopsRefreshAhead(t *testing.T) {
+	cfg := &Config{}
+	now := time.Unix(0, 0)
+	var mu sync.Mutex
+	vc := NewValidatorCache(0, time.Minute)
+	vc.Clock = func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return now
+	}
+	vc.Set("a", Validators{ETag: `"old"`})
+	vc.Set("b", Validators{ETag: `"old"`})
+
+	var computed atomic.Int32
+	vc.RefreshAhead(cfg, 5*time.Millisecond, func(key string) (Validators, error) {
+		computed.Add(1)
+		return Validators{ETag: `"new"`}, nil
+	})
+
+	// Nothing expires within the next interval yet.
+	time.Sleep(20 * time.Millisecond)
+	if computed.Load() != 0 {
+		t.Fatalf("%d keys refreshed early", computed.Load())
+	}
+
+	mu.Lock()
+	now = now.Add(time.Minute - time.Millisecond)
+	mu.Unlock()
+	for deadline := time.Now().Add(time.Second); computed.Load() < 2; time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("expiring keys not refreshed")
+		}
+	}
+	if v, _ := vc.Get("a"); v.ETag != `"new"` {
+		t.Errorf("a = %q after refresh", v.ETag)
+	}
+
+	if err := cfg.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	n := computed.Load()
+	mu.Lock()
+	now = now.Add(time.Hour)
+	mu.Unlock()
+	time.Sleep(20 * time.Millisecond)
+	if computed.Load() != n {
+		t.Error("refresher kept running after Close")
+	}
+}
@@ -0,0 +1,214 @@
+package conditional
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Sidecar exposes the evaluator over HTTP, so services in any language
+// can ask whether a request should get a 304 or 412. Validators are kept
+// per resource key in a ValidatorStore:
+//
+//	PUT    /validators/{key}  body: {"etag": ..., "last_modified": ...}
+//	DELETE /validators/{key}
+//	POST   /evaluate          body: {"method": ..., "key": ..., "header": {...}}
+//
+// /evaluate answers with the status the service should send, along with
+// the headers to send with it.
+type Sidecar struct {
+	cfg    *Config
+	store  ValidatorStore
+	engine *gin.Engine
+}
+
+// EvaluateRequest describes the client request to evaluate.
+type EvaluateRequest struct {
+	Method string      `json:"method"`
+	Key    string      `json:"key"`
+	Header http.Header `json:"header"`
+}
+
+// EvaluateResponse is the decision for an EvaluateRequest. Status is 200
+// when the service should serve the request normally, with Range false if
+// a Range header must be ignored.
+type EvaluateResponse struct {
+	Status int         `json:"status"`
+	Range  bool        `json:"range"`
+	Header http.Header `json:"header,omitempty"`
+}
+
+// NewSidecar returns a sidecar keeping validators in store, an unbounded
+// ValidatorCache when nil. Sharing a store such as Redis lets several
+// sidecars answer for the same resources.
+func NewSidecar(cfg *Config, store ValidatorStore) *Sidecar {
+	if cfg == nil {
+		cfg = DefaultConfig
+	}
+	if store == nil {
+		store = NewValidatorCache(0, 0).Store()
+	}
+	s := &Sidecar{cfg: cfg, store: store, engine: gin.New()}
+	s.engine.NoRoute(s.evaluate)
+	return s
+}
+
+func (s *Sidecar) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.URL.Path == "/evaluate" && r.Method == http.MethodPost:
+		s.serveEvaluate(w, r)
+	case strings.HasPrefix(r.URL.Path, "/validators/"):
+		s.serveValidators(w, r, strings.TrimPrefix(r.URL.Path, "/validators/"))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *Sidecar) serveValidators(w http.ResponseWriter, r *http.Request, key string) {
+	var err error
+	switch r.Method {
+	case http.MethodPut:
+		var v Validators
+		if err := json.NewDecoder(r.Body).Decode(&v); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		err = s.Set(r.Context(), key, v)
+	case http.MethodDelete:
+		err = s.Invalidate(r.Context(), key)
+	default:
+		w.Header().Set("Allow", "PUT, DELETE")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Sidecar) serveEvaluate(w http.ResponseWriter, r *http.Request) {
+	var req EvaluateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	resp, err := s.Evaluate(r.Context(), req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// Set stores the current validators of the resource at key.
+func (s *Sidecar) Set(ctx context.Context, key string, v Validators) error {
+	old, existed, err := s.store.Get(ctx, key)
+	if err != nil {
+		return err
+	}
+	if err := s.store.Set(ctx, key, v); err != nil {
+		return err
+	}
+
+	if existed && (old.ETag != v.ETag || !old.LastModified.Equal(v.LastModified)) {
+		s.cfg.ResourceChanged(key)
+	}
+	return nil
+}
+
+// Invalidate forgets the resource at key, it is then treated as absent.
+func (s *Sidecar) Invalidate(ctx context.Context, key string) error {
+	_, existed, err := s.store.Get(ctx, key)
+	if err != nil {
+		return err
+	}
+	if err := s.store.Invalidate(ctx, key); err != nil {
+		return err
+	}
+
+	if existed {
+		s.cfg.ResourceChanged(key)
+	}
+	return nil
+}
+
+// Evaluate runs the evaluator against the stored validators of req.Key.
+func (s *Sidecar) Evaluate(ctx context.Context, req EvaluateRequest) (EvaluateResponse, error) {
+	v, ok, err := s.store.Get(ctx, req.Key)
+	if err != nil {
+		return EvaluateResponse{}, err
+	}
+
+	method := req.Method
+	if method == "" {
+		method = Get
+	}
+	ev := &evaluation{found: ok}
+	if ok {
+		ev.resource = v.Resource()
+	}
+	httpReq, err := http.NewRequestWithContext(context.WithValue(ctx, evaluationKey{}, ev), method, "/", nil)
+	if err != nil {
+		return EvaluateResponse{Status: http.StatusBadRequest}, nil
+	}
+	if req.Header != nil {
+		httpReq.Header = req.Header
+	}
+
+	// The engine routes every request to s.evaluate, which fills in ev.
+	s.engine.ServeHTTP(&discardWriter{header: http.Header{}}, httpReq)
+	return ev.resp, nil
+}
+
+// evaluation carries an Evaluate call through the engine.
+type evaluation struct {
+	resource interface{}
+	found    bool
+	resp     EvaluateResponse
+}
+
+type evaluationKey struct{}
+
+func (s *Sidecar) evaluate(c *gin.Context) {
+	ev := c.Request.Context().Value(evaluationKey{}).(*evaluation)
+
+	handled, err := s.cfg.Conditional(c, ev.resource)
+	// Cloned before the engine adds its own headers to unanswered
+	// requests.
+	ev.resp = EvaluateResponse{Status: http.StatusOK, Range: true, Header: c.Writer.Header().Clone()}
+	switch {
+	case handled:
+		ev.resp.Status = c.Writer.Status()
+	case errors.Is(err, ErrRangeMismatch):
+		ev.resp.Range = false
+	case err != nil:
+		ev.resp.Status = errorStatus(err)
+	case !ev.found && (c.Request.Method == Get || c.Request.Method == Head):
+		// Absence is only evaluated along with preconditions.
+		ev.resp.Status = http.StatusNotFound
+	}
+}
+
+// discardWriter is the response of an evaluation, only its header is read
+// back.
+type discardWriter struct {
+	header http.Header
+}
+
+func (w *discardWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *discardWriter) Write(p []byte) (int, error) {
+	return len(p), nil
+}
+
+func (w *discardWriter) WriteHeader(int) {}
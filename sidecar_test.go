@@ -0,0 +1,79 @@
+package conditional
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSidecarEvaluate(t *testing.T) {
+	ctx := context.Background()
+	s := NewSidecar(&Config{}, nil)
+	s.Set(ctx, "a", Validators{ETag: `"a"`, LastModified: time.Unix(1e9, 0)})
+
+	for _, test := range []struct {
+		req    EvaluateRequest
+		status int
+		ranges bool
+	}{
+		{EvaluateRequest{Key: "a", Header: http.Header{IfNoneMatch: {`"a"`}}}, http.StatusNotModified, true},
+		{EvaluateRequest{Key: "a", Header: http.Header{IfNoneMatch: {`"b"`}}}, http.StatusOK, true},
+		{EvaluateRequest{Method: Put, Key: "a", Header: http.Header{IfMatch: {`"b"`}}}, http.StatusPreconditionFailed, true},
+		{EvaluateRequest{Key: "a", Header: http.Header{Range: {"bytes=0-1"}, IfRange: {`"b"`}}}, http.StatusOK, false},
+		{EvaluateRequest{Key: "missing"}, http.StatusNotFound, true},
+		{EvaluateRequest{Method: "PROPFIND", Key: "a", Header: http.Header{IfMatch: {`"b"`}}}, http.StatusPreconditionFailed, true},
+	} {
+		resp, err := s.Evaluate(ctx, test.req)
+		if err != nil || resp.Status != test.status || resp.Range != test.ranges {
+			t.Errorf("%+v: got %d, range %v, %v, want %d, range %v", test.req, resp.Status, resp.Range, err, test.status, test.ranges)
+		}
+	}
+
+	resp, _ := s.Evaluate(ctx, EvaluateRequest{Key: "a"})
+	if resp.Header.Get(ETag) != `"a"` {
+		t.Errorf("ETag = %q, want \"a\"", resp.Header.Get(ETag))
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "" {
+		t.Errorf("Content-Type = %q leaked from the engine", ct)
+	}
+}
+
+func TestSidecarSharedStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewValidatorCache(0, 0).Store()
+	writer, reader := NewSidecar(&Config{}, store), NewSidecar(&Config{}, store)
+
+	writer.Set(ctx, "a", Validators{ETag: `"a"`})
+	resp, err := reader.Evaluate(ctx, EvaluateRequest{Key: "a", Header: http.Header{IfNoneMatch: {`"a"`}}})
+	if err != nil || resp.Status != http.StatusNotModified {
+		t.Errorf("got %d, %v, want 304 from the shared store", resp.Status, err)
+	}
+
+	writer.Invalidate(ctx, "a")
+	if resp, _ := reader.Evaluate(ctx, EvaluateRequest{Key: "a"}); resp.Status != http.StatusNotFound {
+		t.Errorf("got %d after Invalidate, want 404", resp.Status)
+	}
+}
+
+func TestSidecarStoreErrors(t *testing.T) {
+	s := NewSidecar(&Config{}, failingStore{})
+
+	if _, err := s.Evaluate(context.Background(), EvaluateRequest{Key: "a"}); err == nil {
+		t.Error("Evaluate: no error from a failing store")
+	}
+
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/evaluate", strings.NewReader(`{"key": "a"}`)))
+	if w.Code != http.StatusBadGateway {
+		t.Errorf("POST /evaluate: got %d, want 502", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/validators/a", strings.NewReader(`{"etag": "\"a\""}`)))
+	if w.Code != http.StatusBadGateway {
+		t.Errorf("PUT /validators/a: got %d, want 502", w.Code)
+	}
+}
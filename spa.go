@@ -0,0 +1,26 @@
+package conditional
+
+import (
+	"path"
+	"strings"
+)
+
+// SPA serves a single page application: paths without a file extension
+// that match no file get the index document, and it is sent with
+// "Cache-Control: no-cache" so browsers revalidate it by ETag on every
+// navigation. Fingerprinted assets are cached as immutable, as with
+// Fingerprinted(nil). Missing paths with an extension, such as a removed
+// bundle, still continue down the chain.
+func SPA(index string) Option {
+	return func(s *fileServer) {
+		s.fallback = strings.TrimPrefix(index, "/")
+		if s.fingerprint == nil {
+			s.fingerprint = DefaultFingerprint
+		}
+	}
+}
+
+// Reports whether a missing name falls back to the index document.
+func (s *fileServer) fallsBack(name string) bool {
+	return s.fallback != "" && path.Ext(name) == ""
+}
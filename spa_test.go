@@ -0,0 +1,49 @@
+package conditional
+
+import (
+	"net/http"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestSPA(t *testing.T) {
+	modTime := time.Unix(1700000000, 0)
+	fsys := fstest.MapFS{
+		"index.html":     {Data: []byte("<app>"), ModTime: modTime},
+		"app.3f9c2d.js":  {Data: []byte("app"), ModTime: modTime},
+		"robots.txt":     {Data: []byte("robots"), ModTime: modTime},
+		"nested/app.css": {Data: []byte("css"), ModTime: modTime},
+	}
+	r := staticRouter(&Config{}, fsys, SPA("/index.html"))
+	indexEtag := EtagFromFileInfo(must(fsys.Stat("index.html")))
+
+	tests := []struct {
+		name         string
+		path         string
+		header       map[string]string
+		status       int
+		body         string
+		cacheControl string
+	}{
+		{"route", "/assets/users/42", nil, http.StatusOK, "<app>", "no-cache"},
+		{"route revalidated", "/assets/users/42", map[string]string{IfNoneMatch: indexEtag}, http.StatusNotModified, "", "no-cache"},
+		{"root", "/assets/", nil, http.StatusOK, "<app>", "no-cache"},
+		{"index", "/assets/index.html", nil, http.StatusOK, "<app>", "no-cache"},
+		{"asset", "/assets/robots.txt", nil, http.StatusOK, "robots", ""},
+		{"nested asset", "/assets/nested/app.css", nil, http.StatusOK, "css", ""},
+		{"fingerprinted", "/assets/app.3f9c2d.js", nil, http.StatusOK, "app", "public, max-age=31536000, immutable"},
+		{"missing asset", "/assets/app.0000000.js", nil, http.StatusTeapot, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := request(r, Get, tt.path, tt.header)
+			if w.Code != tt.status || w.Body.String() != tt.body {
+				t.Errorf("got %d %q, want %d %q", w.Code, w.Body, tt.status, tt.body)
+			}
+			if got := w.Header().Get("Cache-Control"); got != tt.cacheControl {
+				t.Errorf("Cache-Control = %q, want %q", got, tt.cacheControl)
+			}
+		})
+	}
+}
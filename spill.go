@@ -0,0 +1,184 @@
+package conditional
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+)
+
+// An error returned by SpillBuffer when the memory limit was reached
+// and spilling to disk is disabled.
+var ErrBufferFull = errors.New("Buffer limit exceeded")
+
+// SpillBuffer holds a response body in memory up to MemLimit bytes.
+// When Spill is set, writes past the limit move the body into a
+// temporary file instead of failing, so ETags can still be computed
+// and ranges served for medium-large responses.
+//
+// Close must always be called, it removes any temporary file.
+type SpillBuffer struct {
+	// Bytes held in memory before spilling, or failing.
+	MemLimit int64
+
+	// Move the body to a temporary file once MemLimit is exceeded.
+	Spill bool
+
+	// Directory for temporary files, os.TempDir when empty.
+	TempDir string
+
+	mem    *bytes.Buffer
+	file   *os.File
+	size   int64
+	closed bool
+}
+
+func NewSpillBuffer(memLimit int64, spill bool) *SpillBuffer {
+	return &SpillBuffer{MemLimit: memLimit, Spill: spill}
+}
+
+func (b *SpillBuffer) Write(p []byte) (int, error) {
+	if b.closed {
+		return 0, os.ErrClosed
+	}
+
+	if b.file == nil && b.size+int64(len(p)) <= b.MemLimit {
+		if b.mem == nil {
+			b.mem = getBuffer()
+		}
+		n, err := b.mem.Write(p)
+		b.size += int64(n)
+		return n, err
+	}
+
+	if b.file == nil {
+		if !b.Spill {
+			return 0, ErrBufferFull
+		}
+		if err := b.spill(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := b.file.Write(p)
+	b.size += int64(n)
+	return n, err
+}
+
+// Moves the in-memory contents into a new temporary file.
+func (b *SpillBuffer) spill() error {
+	f, err := os.CreateTemp(b.TempDir, "gin-conditional-*")
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.Write(b.Bytes()); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+
+	b.file = f
+	b.releaseMem()
+	return nil
+}
+
+// Len returns the number of bytes written so far.
+func (b *SpillBuffer) Len() int64 {
+	return b.size
+}
+
+// Spilled reports whether the contents were moved to disk.
+func (b *SpillBuffer) Spilled() bool {
+	return b.file != nil
+}
+
+// Bytes returns the buffered contents when they are held in memory,
+// or nil once the buffer has spilled. It is only valid until Close.
+func (b *SpillBuffer) Bytes() []byte {
+	if b.file != nil || b.mem == nil {
+		return nil
+	}
+	return b.mem.Bytes()
+}
+
+// ReadSeeker returns a reader over the buffered contents. It is only
+// valid until Close and does not consume the buffer.
+func (b *SpillBuffer) ReadSeeker() io.ReadSeeker {
+	if b.file != nil {
+		return io.NewSectionReader(b.file, 0, b.size)
+	}
+	return bytes.NewReader(b.Bytes())
+}
+
+// WriteTo copies the buffered contents to w.
+func (b *SpillBuffer) WriteTo(w io.Writer) (int64, error) {
+	return io.Copy(w, b.ReadSeeker())
+}
+
+// Close releases the buffer and removes any temporary file. It is safe
+// to call more than once, which makes it suitable for deferring on
+// every path including aborted requests.
+func (b *SpillBuffer) Close() error {
+	if b.closed {
+		return nil
+	}
+	b.closed = true
+	b.releaseMem()
+
+	if b.file == nil {
+		return nil
+	}
+
+	name := b.file.Name()
+	err := b.file.Close()
+	if rerr := os.Remove(name); err == nil {
+		err = rerr
+	}
+	b.file = nil
+	return err
+}
+
+// Returns the memory buffer to the pool.
+func (b *SpillBuffer) releaseMem() {
+	if b.mem != nil {
+		putBuffer(b.mem, b.size)
+		b.mem = nil
+	}
+}
+
+// Memory buffers are pooled across SpillBuffers, so buffering responses
+// does not churn the GC. New buffers are grown to the recent average body
+// size, and buffers far larger than it are dropped rather than pooled, so
+// one large response does not keep its memory alive.
+var (
+	bufferPool  sync.Pool
+	averageSize atomic.Int64
+)
+
+// Buffers up to this size are always pooled.
+const minPooledSize = 64 << 10
+
+func getBuffer() *bytes.Buffer {
+	if buf, ok := bufferPool.Get().(*bytes.Buffer); ok {
+		return buf
+	}
+	buf := new(bytes.Buffer)
+	buf.Grow(int(averageSize.Load()))
+	return buf
+}
+
+func putBuffer(buf *bytes.Buffer, size int64) {
+	// A moving average where each body weighs 1/8. Concurrent updates
+	// may be lost, which only makes it less precise.
+	avg := averageSize.Load()
+	averageSize.Store(avg + (size-avg)/8)
+
+	if int64(buf.Cap()) > 4*max(avg, minPooledSize) {
+		return
+	}
+	buf.Reset()
+	bufferPool.Put(buf)
+}
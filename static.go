@@ -0,0 +1,294 @@
+package conditional
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Option configures the file serving of StaticFS.
+type Option func(*fileServer)
+
+// ContentEtags validates files with a hash of their content instead of the
+// nginx compatible metadata ETag. Hashes are cached per file until its
+// size or modification time changes.
+func ContentEtags() Option {
+	return func(s *fileServer) {
+		s.contentEtags = true
+	}
+}
+
+// StaticFS returns a middleware serving the files of fsys under urlPrefix
+// with ETag, Last-Modified, 304 and Range support. Directories are served
+// through their index.html. Requests for other paths, or for files that
+// do not exist, continue down the chain, so it can be used globally:
+//
+//	router.Use(conditional.StaticFS("/assets", assets))
+//
+// Files without a modification time, as in an embed.FS, are always
+// validated by content.
+func StaticFS(urlPrefix string, fsys fs.FS, opts ...Option) gin.HandlerFunc {
+	return DefaultConfig.StaticFS(urlPrefix, fsys, opts...)
+}
+
+func (cfg *Config) StaticFS(urlPrefix string, fsys fs.FS, opts ...Option) gin.HandlerFunc {
+	s := cfg.newFileServer(fsys, opts)
+	s.prefix = "/" + strings.Trim(urlPrefix, "/")
+	return s.handle
+}
+
+// Static serves the directory root under relativePath of r like gin's
+// RouterGroup.Static, registering GET and HEAD routes for
+// relativePath/*filepath, with conditional and Range support added.
+// Replace
+//
+//	router.Static("/assets", "./public")
+//
+// with
+//
+//	conditional.Static(router, "/assets", "./public")
+//
+// Files that do not exist get a 404.
+func Static(r gin.IRoutes, relativePath, root string) gin.IRoutes {
+	return DefaultConfig.Static(r, relativePath, root)
+}
+
+func (cfg *Config) Static(r gin.IRoutes, relativePath, root string) gin.IRoutes {
+	if strings.Contains(relativePath, ":") || strings.Contains(relativePath, "*") {
+		panic("URL parameters can not be used when serving a static folder")
+	}
+	s := cfg.newFileServer(os.DirFS(root), nil)
+	handler := func(c *gin.Context) {
+		if name, ok := fileName(c.Param("filepath")); !ok || !s.serveFile(c, name) {
+			c.AbortWithStatus(http.StatusNotFound)
+		}
+	}
+
+	urlPattern := path.Join(relativePath, "/*filepath")
+	r.GET(urlPattern, handler)
+	return r.HEAD(urlPattern, handler)
+}
+
+func (cfg *Config) newFileServer(fsys fs.FS, opts []Option) *fileServer {
+	s := &fileServer{
+		cfg:    cfg,
+		fsys:   fsys,
+		hashes: make(map[string]fileHash),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.watch()
+	return s
+}
+
+type fileServer struct {
+	cfg          *Config
+	fsys         fs.FS
+	prefix       string
+	contentEtags bool
+	manifest     Manifest
+	fingerprint  *regexp.Regexp
+	listings     bool
+	fallback     string
+
+	poll          time.Duration
+	invalidations <-chan string
+
+	mu     sync.Mutex
+	hashes map[string]fileHash
+}
+
+// A content ETag, valid while the file keeps its size and mtime.
+type fileHash struct {
+	size    int64
+	modTime time.Time
+	etag    string
+}
+
+// Reports whether the hash was taken of the file fi describes.
+func (h fileHash) current(fi fs.FileInfo) bool {
+	return h.size == fi.Size() && h.modTime.Equal(fi.ModTime())
+}
+
+func (s *fileServer) handle(c *gin.Context) {
+	if c.Request.Method != Get && c.Request.Method != Head {
+		c.Next()
+		return
+	}
+
+	name, ok := s.name(c.Request.URL.Path)
+	if !ok || !s.serveFile(c, name) {
+		c.Next()
+	}
+}
+
+// Serves the file at name, reporting false without answering when it does
+// not exist.
+func (s *fileServer) serveFile(c *gin.Context, name string) bool {
+	file, err := s.open(name)
+	if errors.Is(err, fs.ErrNotExist) && s.listings && s.serveListing(c, name) {
+		c.Abort()
+		return true
+	}
+	if errors.Is(err, fs.ErrNotExist) && s.fallsBack(name) {
+		file, err = s.open(s.fallback)
+	}
+	if errors.Is(err, fs.ErrNotExist) {
+		// Removed files keep no content ETag cached.
+		s.invalidate(name)
+		return false
+	}
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return true
+	}
+
+	if s.fingerprinted(file.name) {
+		s.serveImmutable(c, file)
+	} else {
+		if file.name == s.fallback {
+			CacheControl{NoCache: true}.Apply(c)
+		}
+		s.serve(c, file.name, file)
+	}
+	c.Abort()
+	return true
+}
+
+// Maps a request path to a name in fsys, reporting false for paths outside
+// the prefix.
+func (s *fileServer) name(urlPath string) (string, bool) {
+	p := path.Clean("/" + urlPath)
+	if s.prefix != "/" {
+		if p != s.prefix && !strings.HasPrefix(p, s.prefix+"/") {
+			return "", false
+		}
+		p = p[len(s.prefix):]
+	}
+	return fileName(p)
+}
+
+// Maps a path below the served root to a name in fsys.
+func fileName(p string) (string, bool) {
+	name := strings.TrimPrefix(path.Clean("/"+p), "/")
+	if name == "" {
+		name = "."
+	}
+	return name, fs.ValidPath(name)
+}
+
+// Stats name, resolving directories to their index.html.
+func (s *fileServer) open(name string) (*staticFile, error) {
+	fi, err := fs.Stat(s.fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	if fi.IsDir() {
+		name = path.Join(name, "index.html")
+		if fi, err = fs.Stat(s.fsys, name); err != nil {
+			return nil, err
+		}
+		if fi.IsDir() {
+			return nil, fs.ErrNotExist
+		}
+	}
+	return &staticFile{server: s, name: name, info: fi}, nil
+}
+
+// Serves resource with the Content-Type its name implies.
+func (s *fileServer) serve(c *gin.Context, name string, resource interface{}) {
+	header := c.Writer.Header()
+	if header.Get("Content-Type") == "" {
+		if ctype := mime.TypeByExtension(path.Ext(name)); ctype != "" {
+			header.Set("Content-Type", ctype)
+		}
+	}
+	s.cfg.Serve(c, resource)
+}
+
+// Returns the content ETag of name, hashing it unless the cached hash is
+// still current.
+func (s *fileServer) contentEtag(name string, fi fs.FileInfo) (string, error) {
+	s.mu.Lock()
+	cached, ok := s.hashes[name]
+	s.mu.Unlock()
+	if ok && cached.current(fi) {
+		return cached.etag, nil
+	}
+
+	f, err := s.fsys.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	etag, err := s.cfg.hashEtagReader(f)
+	if err != nil {
+		return "", err
+	}
+
+	s.mu.Lock()
+	s.hashes[name] = fileHash{size: fi.Size(), modTime: fi.ModTime(), etag: etag}
+	s.mu.Unlock()
+	return etag, nil
+}
+
+// staticFile is a file of a fileServer, as a resource for Serve.
+type staticFile struct {
+	server *fileServer
+	name   string
+	info   fs.FileInfo
+}
+
+func (f *staticFile) Etag() (string, error) {
+	if entry, ok := f.server.manifest.lookup(f.name, f.info); ok {
+		return entry.ETag, nil
+	}
+	if f.server.contentEtags || f.info.ModTime().IsZero() {
+		return f.server.contentEtag(f.name, f.info)
+	}
+	return EtagFromFileInfo(f.info), nil
+}
+
+func (f *staticFile) LastModified() time.Time {
+	if entry, ok := f.server.manifest.lookup(f.name, f.info); ok && !entry.ModTime.IsZero() {
+		return entry.ModTime
+	}
+	return f.info.ModTime()
+}
+
+func (f *staticFile) Size() int64 {
+	return f.info.Size()
+}
+
+// Files that cannot seek are read into memory.
+func (f *staticFile) Content() (io.ReadSeeker, int64, error) {
+	file, err := f.server.fsys.Open(f.name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, 0, ErrNoResource
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	if rs, ok := file.(io.ReadSeeker); ok {
+		return rs, f.info.Size(), nil
+	}
+
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, 0, err
+	}
+	return bytes.NewReader(data), int64(len(data)), nil
+}
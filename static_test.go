@@ -0,0 +1,165 @@
+package conditional
+
+import (
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Sends a request carrying header to h.
+func request(h http.Handler, method, path string, header map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	for name, value := range header {
+		req.Header.Set(name, value)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w
+}
+
+// Engine serving fsys under /assets through cfg.StaticFS, answering what
+// it passes on with 418.
+func staticRouter(cfg *Config, fsys fstest.MapFS, opts ...Option) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(cfg.StaticFS("/assets", fsys, opts...))
+	r.NoRoute(func(c *gin.Context) { c.Status(http.StatusTeapot) })
+	return r
+}
+
+func TestStaticFS(t *testing.T) {
+	modTime := time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+	fsys := fstest.MapFS{
+		"app.js":           {Data: []byte("0123456789"), ModTime: modTime},
+		"docs/index.html":  {Data: []byte("<h1>docs</h1>"), ModTime: modTime},
+		"embedded.css":     {Data: []byte("body{}")},
+		"empty/.gitignore": {Data: nil, ModTime: modTime},
+	}
+	r := staticRouter(&Config{}, fsys)
+	etag := EtagFromFileInfo(must(fs.Stat(fsys, "app.js")))
+	lastModified := modTime.Format(http.TimeFormat)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		header map[string]string
+		status int
+		body   string
+	}{
+		{"file", Get, "/assets/app.js", nil, http.StatusOK, "0123456789"},
+		{"head", Head, "/assets/app.js", nil, http.StatusOK, ""},
+		{"etag match", Get, "/assets/app.js", map[string]string{IfNoneMatch: etag}, http.StatusNotModified, ""},
+		{"etag mismatch", Get, "/assets/app.js", map[string]string{IfNoneMatch: `"other"`}, http.StatusOK, "0123456789"},
+		{"not modified since", Get, "/assets/app.js", map[string]string{IfModifiedSince: lastModified}, http.StatusNotModified, ""},
+		{"range", Get, "/assets/app.js", map[string]string{Range: "bytes=2-4"}, http.StatusPartialContent, "234"},
+		{"if-range mismatch", Get, "/assets/app.js", map[string]string{Range: "bytes=2-4", IfRange: `"other"`}, http.StatusOK, "0123456789"},
+		{"directory index", Get, "/assets/docs/", nil, http.StatusOK, "<h1>docs</h1>"},
+		{"missing", Get, "/assets/missing.js", nil, http.StatusTeapot, ""},
+		{"directory without index", Get, "/assets/empty", nil, http.StatusTeapot, ""},
+		{"outside the prefix", Get, "/app.js", nil, http.StatusTeapot, ""},
+		{"escaping the root", Get, "/assets/../app.js", nil, http.StatusTeapot, ""},
+		{"post", http.MethodPost, "/assets/app.js", nil, http.StatusTeapot, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := request(r, tt.method, tt.path, tt.header)
+			if w.Code != tt.status || w.Body.String() != tt.body {
+				t.Errorf("got %d %q, want %d %q", w.Code, w.Body, tt.status, tt.body)
+			}
+		})
+	}
+
+	w := request(r, Get, "/assets/app.js", nil)
+	if got := w.Header().Get(ETag); got != etag {
+		t.Errorf("ETag = %q, want the metadata ETag %q", got, etag)
+	}
+	if got := w.Header().Get(LastModified); got != lastModified {
+		t.Errorf("Last-Modified = %q, want %q", got, lastModified)
+	}
+	if got := w.Header().Get("Content-Type"); got != "text/javascript; charset=utf-8" {
+		t.Errorf("Content-Type = %q", got)
+	}
+
+	// Without a modification time, as in an embed.FS, content decides.
+	w = request(r, Get, "/assets/embedded.css", nil)
+	if want := (&Config{}).EtagFromBytes([]byte("body{}")); w.Header().Get(ETag) != want {
+		t.Errorf("embedded ETag = %q, want the content ETag %q", w.Header().Get(ETag), want)
+	}
+}
+
+func TestStaticFSContentEtags(t *testing.T) {
+	fsys := fstest.MapFS{"app.js": {Data: []byte("app"), ModTime: time.Unix(1700000000, 0)}}
+	r := staticRouter(&Config{}, fsys, ContentEtags())
+
+	want := (&Config{}).EtagFromBytes([]byte("app"))
+	if w := request(r, Get, "/assets/app.js", nil); w.Header().Get(ETag) != want {
+		t.Errorf("ETag = %q, want %q", w.Header().Get(ETag), want)
+	}
+	if w := request(r, Get, "/assets/app.js", map[string]string{IfNoneMatch: want}); w.Code != http.StatusNotModified {
+		t.Errorf("got %d, want 304", w.Code)
+	}
+}
+
+func must[T any](v T, err error) T {
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+func TestStatic(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "app.js"), []byte("app"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	r := gin.New()
+	Static(r.Group("/v1"), "/assets", root)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/assets/app.js", nil))
+	etag := w.Header().Get(ETag)
+	if w.Code != http.StatusOK || w.Body.String() != "app" || etag == "" {
+		t.Fatalf("GET: got %d %q, ETag %q", w.Code, w.Body, etag)
+	}
+
+	for _, test := range []struct {
+		method, path, ifNoneMatch string
+		status                    int
+	}{
+		{http.MethodGet, "/v1/assets/app.js", etag, http.StatusNotModified},
+		{http.MethodHead, "/v1/assets/app.js", "", http.StatusOK},
+		{http.MethodHead, "/v1/assets/app.js", etag, http.StatusNotModified},
+		{http.MethodGet, "/v1/assets/missing.js", "", http.StatusNotFound},
+		{http.MethodGet, "/v1/assets/../static_test.go", "", http.StatusNotFound},
+		{http.MethodPost, "/v1/assets/app.js", "", http.StatusNotFound},
+	} {
+		req := httptest.NewRequest(test.method, test.path, nil)
+		if test.ifNoneMatch != "" {
+			req.Header.Set(IfNoneMatch, test.ifNoneMatch)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != test.status {
+			t.Errorf("%s %s: got %d, want %d", test.method, test.path, w.Code, test.status)
+		}
+	}
+}
+
+func TestStaticRejectsParameters(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("no panic for a relativePath with a parameter")
+		}
+	}()
+	Static(gin.New(), "/assets/:version", ".")
+}
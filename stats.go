@@ -0,0 +1,125 @@
+package conditional
+
+import (
+	"math/rand"
+	"sort"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// KeyStats samples, per resource key, how revalidation requests end: with
+// a 304 or with a full response. Keys whose validators churn on every
+// request, for example because a timestamp is part of the payload, show
+// up with a near-zero 304 rate.
+type KeyStats struct {
+	// Fraction of revalidation requests recorded, all of them when zero.
+	SampleRate float64
+
+	// Upper bound on tracked keys, new keys are dropped once reached.
+	// Unbounded when zero.
+	MaxKeys int
+
+	mu   sync.Mutex
+	keys map[string]*keyCounts
+}
+
+type keyCounts struct {
+	full, notModified uint64
+}
+
+// KeyReport summarises the sampled revalidations of one key.
+type KeyReport struct {
+	Key         string
+	Requests    uint64
+	NotModified uint64
+}
+
+// Ratio is the fraction of sampled revalidations answered with a 304.
+func (r KeyReport) Ratio() float64 {
+	if r.Requests == 0 {
+		return 0
+	}
+	return float64(r.NotModified) / float64(r.Requests)
+}
+
+// Only requests that could have been a 304 are counted.
+func (cfg *Config) recordStats(c *gin.Context, notModified bool) {
+	if c.Request.Method != Get && c.Request.Method != Head {
+		return
+	}
+	if cfg.header(c.Request, IfNoneMatch) == "" && cfg.header(c.Request, IfModifiedSince) == "" {
+		return
+	}
+
+	s := cfg.Stats
+	if s.SampleRate > 0 && rand.Float64() >= s.SampleRate {
+		return
+	}
+	s.record(cfg.key(c), notModified)
+}
+
+func (s *KeyStats) record(key string, notModified bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.keys == nil {
+		s.keys = make(map[string]*keyCounts)
+	}
+
+	counts, ok := s.keys[key]
+	if !ok {
+		if s.MaxKeys > 0 && len(s.keys) >= s.MaxKeys {
+			return
+		}
+		counts = &keyCounts{}
+		s.keys[key] = counts
+	}
+
+	if notModified {
+		counts.notModified++
+	} else {
+		counts.full++
+	}
+}
+
+// Churning returns the keys with at least minRequests sampled
+// revalidations whose 304 rate is at most maxRatio, busiest first.
+func (s *KeyStats) Churning(minRequests uint64, maxRatio float64) []KeyReport {
+	var reports []KeyReport
+	for _, report := range s.Report() {
+		if report.Requests >= minRequests && report.Ratio() <= maxRatio {
+			reports = append(reports, report)
+		}
+	}
+	return reports
+}
+
+// Report returns every tracked key, busiest first.
+func (s *KeyStats) Report() []KeyReport {
+	s.mu.Lock()
+	reports := make([]KeyReport, 0, len(s.keys))
+	for key, counts := range s.keys {
+		reports = append(reports, KeyReport{
+			Key:         key,
+			Requests:    counts.full + counts.notModified,
+			NotModified: counts.notModified,
+		})
+	}
+	s.mu.Unlock()
+
+	sort.Slice(reports, func(i, j int) bool {
+		if reports[i].Requests != reports[j].Requests {
+			return reports[i].Requests > reports[j].Requests
+		}
+		return reports[i].Key < reports[j].Key
+	})
+	return reports
+}
+
+// Reset forgets every tracked key.
+func (s *KeyStats) Reset() {
+	s.mu.Lock()
+	s.keys = nil
+	s.mu.Unlock()
+}
@@ -0,0 +1,88 @@
+package conditional
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestKeyStats(t *testing.T) {
+	modified := time.Date(2001, 9, 9, 1, 46, 40, 0, time.UTC)
+	stats := &KeyStats{}
+	cfg := &Config{Stats: stats, Key: func(c *gin.Context) string { return c.Request.Header.Get("X-Key") }}
+	resource := BytesResource(nil, `"a"`, modified)
+
+	for _, req := range []struct {
+		method string
+		header map[string]string
+	}{
+		{Get, map[string]string{"X-Key": "stable", IfNoneMatch: `"a"`}},
+		{Get, map[string]string{"X-Key": "stable", IfNoneMatch: `"a"`}},
+		{Head, map[string]string{"X-Key": "stable", IfNoneMatch: `"b"`}},
+		{Get, map[string]string{"X-Key": "churning", IfNoneMatch: `"b"`}},
+		{Get, map[string]string{"X-Key": "churning", IfModifiedSince: modified.Add(-time.Hour).Format(http.TimeFormat)}},
+		{Get, map[string]string{"X-Key": "churning", IfNoneMatch: `"b"`}},
+		// Not revalidations.
+		{Get, map[string]string{"X-Key": "plain"}},
+		{Put, map[string]string{"X-Key": "write", IfMatch: `"a"`}},
+	} {
+		runConditional(cfg, req.method, req.header, resource)
+	}
+
+	want := []KeyReport{{Key: "churning", Requests: 3}, {Key: "stable", Requests: 3, NotModified: 2}}
+	if got := stats.Report(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Report() = %+v, want %+v", got, want)
+	}
+	if got := stats.Churning(3, 0.1); !reflect.DeepEqual(got, want[:1]) {
+		t.Errorf("Churning(3, 0.1) = %+v, want %+v", got, want[:1])
+	}
+	if got := stats.Churning(4, 1); len(got) != 0 {
+		t.Errorf("Churning(4, 1) = %+v, want none", got)
+	}
+
+	stats.Reset()
+	if got := stats.Report(); len(got) != 0 {
+		t.Errorf("Report() after Reset = %+v", got)
+	}
+}
+
+func TestKeyStatsLimits(t *testing.T) {
+	tests := []struct {
+		name  string
+		stats *KeyStats
+		keys  int
+	}{
+		{"unbounded", &KeyStats{}, 10},
+		{"max keys", &KeyStats{MaxKeys: 3}, 3},
+		{"sampled out", &KeyStats{SampleRate: 1e-300}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{Stats: tt.stats, Key: func(c *gin.Context) string { return c.Request.Header.Get("X-Key") }}
+			for i := 0; i < 10; i++ {
+				runConditional(cfg, Get, map[string]string{"X-Key": string(rune('a' + i)), IfNoneMatch: `"a"`}, BytesResource(nil, `"a"`, time.Time{}))
+			}
+			if got := len(tt.stats.Report()); got != tt.keys {
+				t.Errorf("%d keys tracked, want %d", got, tt.keys)
+			}
+		})
+	}
+}
+
+func TestKeyReportRatio(t *testing.T) {
+	for _, tt := range []struct {
+		report KeyReport
+		want   float64
+	}{
+		{KeyReport{}, 0},
+		{KeyReport{Requests: 4, NotModified: 1}, 0.25},
+		{KeyReport{Requests: 2, NotModified: 2}, 1},
+	} {
+		if got := tt.report.Ratio(); got != tt.want {
+			t.Errorf("%+v: Ratio() = %v, want %v", tt.report, got, tt.want)
+		}
+	}
+}
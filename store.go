@@ -0,0 +1,184 @@
+package conditional
+
+import (
+	"context"
+	"log"
+)
+
+// ValidatorStore holds computed Validators per resource key, such as in
+// Redis, so the instances of a horizontally scaled service share them and
+// their invalidations instead of each recomputing its own.
+type ValidatorStore interface {
+	// Get returns the validators stored for key, false when there are
+	// none.
+	Get(ctx context.Context, key string) (Validators, bool, error)
+
+	Set(ctx context.Context, key string, v Validators) error
+
+	// Invalidate drops the validators of key, after the resource
+	// changed.
+	Invalidate(ctx context.Context, key string) error
+}
+
+// Store adapts the cache to a ValidatorStore, for use as the in-process
+// tier or in tests.
+func (vc *ValidatorCache) Store() ValidatorStore {
+	return cacheStore{vc}
+}
+
+type cacheStore struct {
+	cache *ValidatorCache
+}
+
+func (s cacheStore) Get(ctx context.Context, key string) (Validators, bool, error) {
+	v, ok := s.cache.Get(key)
+	return v, ok, nil
+}
+
+func (s cacheStore) Set(ctx context.Context, key string, v Validators) error {
+	s.cache.Set(key, v)
+	return nil
+}
+
+func (s cacheStore) Invalidate(ctx context.Context, key string) error {
+	s.cache.Invalidate(key)
+	return nil
+}
+
+// StoredResource returns the validators of key in store as a resource for
+// Conditional, calling compute and storing its result on a miss. A store
+// that fails is bypassed, so an outage only costs recomputation.
+func StoredResource(ctx context.Context, store ValidatorStore, key string, compute func() (Validators, error)) (interface{}, error) {
+	if v, ok, err := store.Get(ctx, key); err == nil && ok {
+		return v.Resource(), nil
+	}
+
+	v, err := compute()
+	if err != nil {
+		return nil, err
+	}
+	store.Set(ctx, key, v)
+	return v.Resource(), nil
+}
+
+// StoreInvalidator returns an Invalidator dropping changed resources from
+// store. Failures are logged to errorLog, the log package's standard
+// logger when nil.
+func StoreInvalidator(store ValidatorStore, errorLog *log.Logger) Invalidator {
+	return InvalidatorFunc(func(key string) {
+		ctx, cancel := context.WithTimeout(context.Background(), purgeTimeout)
+		defer cancel()
+		if err := store.Invalidate(ctx, key); err == nil {
+			return
+		} else if errorLog != nil {
+			errorLog.Printf("conditional: invalidating %s: %v", key, err)
+		} else {
+			log.Printf("conditional: invalidating %s: %v", key, err)
+		}
+	})
+}
+
+// BatchValidatorStore is a ValidatorStore reading and writing many keys at
+// once, so collection endpoints fetch the validators of a page of items in
+// one round trip. Keys without validators are left out of GetValidators'
+// result.
+type BatchValidatorStore interface {
+	ValidatorStore
+	GetValidators(ctx context.Context, keys []string) (map[string]Validators, error)
+	SetValidators(ctx context.Context, validators map[string]Validators) error
+}
+
+func (s cacheStore) GetValidators(ctx context.Context, keys []string) (map[string]Validators, error) {
+	found := make(map[string]Validators, len(keys))
+	for _, key := range keys {
+		if v, ok := s.cache.Get(key); ok {
+			found[key] = v
+		}
+	}
+	return found, nil
+}
+
+func (s cacheStore) SetValidators(ctx context.Context, validators map[string]Validators) error {
+	for key, v := range validators {
+		s.cache.Set(key, v)
+	}
+	return nil
+}
+
+// GetValidators returns the validators stored for keys, in one call when
+// store is a BatchValidatorStore and key by key otherwise. Validators
+// missing from the store are computed together by compute, which may be
+// nil to leave them out, and stored. A store that fails is bypassed.
+func GetValidators(ctx context.Context, store ValidatorStore, keys []string, compute func(missing []string) (map[string]Validators, error)) (map[string]Validators, error) {
+	found, err := lookupValidators(ctx, store, keys)
+	if err != nil {
+		found = make(map[string]Validators, len(keys))
+	}
+
+	var missing []string
+	for _, key := range keys {
+		if _, ok := found[key]; !ok {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) == 0 || compute == nil {
+		return found, nil
+	}
+
+	computed, err := compute(missing)
+	if err != nil {
+		return nil, err
+	}
+	for key, v := range computed {
+		found[key] = v
+	}
+	if batch, ok := store.(BatchValidatorStore); ok {
+		batch.SetValidators(ctx, computed)
+	} else {
+		for key, v := range computed {
+			store.Set(ctx, key, v)
+		}
+	}
+	return found, nil
+}
+
+func lookupValidators(ctx context.Context, store ValidatorStore, keys []string) (map[string]Validators, error) {
+	if batch, ok := store.(BatchValidatorStore); ok {
+		return batch.GetValidators(ctx, keys)
+	}
+
+	found := make(map[string]Validators, len(keys))
+	for _, key := range keys {
+		v, ok, err := store.Get(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			found[key] = v
+		}
+	}
+	return found, nil
+}
+
+// AggregateValidators returns the validators of a collection from those
+// of its items: a strong ETag over the items' ETags in order, changing
+// when any item, or the order, changes, and the latest Last-Modified.
+// Items without validators count as empty.
+func AggregateValidators(keys []string, items map[string]Validators) Validators {
+	return DefaultConfig.AggregateValidators(keys, items)
+}
+
+func (cfg *Config) AggregateValidators(keys []string, items map[string]Validators) Validators {
+	var v Validators
+	h := cfg.newHash()
+	for _, key := range keys {
+		item := items[key]
+		h.Write([]byte(key))
+		h.Write([]byte{0})
+		h.Write([]byte(item.ETag))
+		h.Write([]byte{0})
+		v.LastModified = LatestOf(v.LastModified, item.LastModified)
+	}
+	v.ETag = cfg.etagFromSum(h.Sum(nil))
+	return v
+}
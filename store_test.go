@@ -0,0 +1,147 @@
+package conditional
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// keyByKeyStore hides the batch methods of the store it wraps.
+type keyByKeyStore struct {
+	ValidatorStore
+}
+
+// countingBatchStore counts the round trips to the store it wraps.
+type countingBatchStore struct {
+	BatchValidatorStore
+	gets, sets int
+}
+
+func (s *countingBatchStore) GetValidators(ctx context.Context, keys []string) (map[string]Validators, error) {
+	s.gets++
+	return s.BatchValidatorStore.GetValidators(ctx, keys)
+}
+
+func (s *countingBatchStore) SetValidators(ctx context.Context, validators map[string]Validators) error {
+	s.sets++
+	return s.BatchValidatorStore.SetValidators(ctx, validators)
+}
+
+func TestGetValidators(t *testing.T) {
+	ctx := context.Background()
+	keys := []string{"a", "b", "c"}
+	computeMissing := func(missing []string) (map[string]Validators, error) {
+		computed := map[string]Validators{}
+		for _, key := range missing {
+			computed[key] = Validators{ETag: `"computed-` + key + `"`}
+		}
+		return computed, nil
+	}
+	want := map[string]Validators{
+		"a": {ETag: `"a"`},
+		"b": {ETag: `"computed-b"`},
+		"c": {ETag: `"computed-c"`},
+	}
+
+	for _, batch := range []bool{true, false} {
+		cache := NewValidatorCache(0, 0)
+		cache.Set("a", Validators{ETag: `"a"`})
+		counting := &countingBatchStore{BatchValidatorStore: cache.Store().(BatchValidatorStore)}
+		var store ValidatorStore = counting
+		if !batch {
+			store = keyByKeyStore{counting}
+		}
+
+		got, err := GetValidators(ctx, store, keys, computeMissing)
+		if err != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("batch %v: got %v, %v, want %v", batch, got, err, want)
+		}
+		if v, ok := cache.Get("c"); !ok || v.ETag != `"computed-c"` {
+			t.Errorf("batch %v: computed validators not stored", batch)
+		}
+		if batch && (counting.gets != 1 || counting.sets != 1) {
+			t.Errorf("%d batch reads and %d batch writes, want one each", counting.gets, counting.sets)
+		}
+		if !batch && (counting.gets != 0 || counting.sets != 0) {
+			t.Errorf("batch methods used through a key by key store")
+		}
+	}
+}
+
+func TestGetValidatorsMissing(t *testing.T) {
+	ctx := context.Background()
+	cache := NewValidatorCache(0, 0)
+	cache.Set("a", Validators{ETag: `"a"`})
+
+	got, err := GetValidators(ctx, cache.Store(), []string{"a", "b"}, nil)
+	if err != nil || !reflect.DeepEqual(got, map[string]Validators{"a": {ETag: `"a"`}}) {
+		t.Errorf("without compute: got %v, %v", got, err)
+	}
+
+	called := false
+	GetValidators(ctx, cache.Store(), []string{"a"}, func([]string) (map[string]Validators, error) {
+		called = true
+		return nil, nil
+	})
+	if called {
+		t.Error("compute called with nothing missing")
+	}
+
+	if _, err := GetValidators(ctx, cache.Store(), []string{"b"}, func([]string) (map[string]Validators, error) {
+		return nil, errBackend
+	}); !errors.Is(err, errBackend) {
+		t.Errorf("got %v, want the compute error", err)
+	}
+
+	// A failing store is bypassed, everything is computed.
+	got, err = GetValidators(ctx, failingStore{}, []string{"a"}, func(missing []string) (map[string]Validators, error) {
+		return map[string]Validators{"a": {ETag: `"computed"`}}, nil
+	})
+	if err != nil || got["a"].ETag != `"computed"` {
+		t.Errorf("failing store: got %v, %v", got, err)
+	}
+}
+
+func TestAggregateValidators(t *testing.T) {
+	t1 := time.Date(2001, 9, 9, 1, 46, 40, 0, time.UTC)
+	t2 := t1.Add(time.Hour)
+	items := map[string]Validators{
+		"a": {ETag: `"a"`, LastModified: t1},
+		"b": {ETag: `"b"`, LastModified: t2},
+	}
+	base := AggregateValidators([]string{"a", "b"}, items)
+	if !base.LastModified.Equal(t2) {
+		t.Errorf("LastModified = %v, want the latest %v", base.LastModified, t2)
+	}
+	if isWeak(base.ETag) {
+		t.Errorf("ETag %s is weak", base.ETag)
+	}
+	if again := AggregateValidators([]string{"a", "b"}, items); again != base {
+		t.Errorf("not stable: %v then %v", base, again)
+	}
+
+	changed := map[string]Validators{"a": {ETag: `"a2"`, LastModified: t1}, "b": items["b"]}
+	tests := []struct {
+		name  string
+		keys  []string
+		items map[string]Validators
+	}{
+		{"reordered", []string{"b", "a"}, items},
+		{"item changed", []string{"a", "b"}, changed},
+		{"item added", []string{"a", "b", "c"}, items},
+		{"item removed", []string{"a"}, items},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := AggregateValidators(tt.keys, tt.items); got.ETag == base.ETag {
+				t.Errorf("ETag unchanged: %s", got.ETag)
+			}
+		})
+	}
+
+	if empty := AggregateValidators(nil, nil); empty.ETag == "" || !empty.LastModified.IsZero() {
+		t.Errorf("empty collection: %v", empty)
+	}
+}
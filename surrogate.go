@@ -0,0 +1,50 @@
+package conditional
+
+import (
+	"net/http"
+	"strings"
+)
+
+// SurrogateKeyer can be implemented by resources to tag their responses
+// with Surrogate-Key, so Fastly, Varnish and other CDNs can purge every
+// cached representation of the resource by key.
+type SurrogateKeyer interface {
+	SurrogateKeys() []string
+}
+
+// SurrogateController can be implemented by resources to give CDNs a
+// freshness policy of their own through Surrogate-Control, which they
+// strip before responses reach browsers.
+type SurrogateController interface {
+	SurrogateControl() CacheControl
+}
+
+// Sets the surrogate headers resource declares, keeping keys a handler
+// already added.
+func setSurrogate(header http.Header, resource interface{}) {
+	if s, ok := resource.(SurrogateKeyer); ok {
+		keys := strings.Fields(header.Get("Surrogate-Key"))
+		present := map[string]bool{}
+		for _, key := range keys {
+			present[key] = true
+		}
+		for _, key := range s.SurrogateKeys() {
+			// Keys are space separated, so a key cannot contain spaces.
+			for _, key := range strings.Fields(key) {
+				if !present[key] {
+					keys = append(keys, key)
+					present[key] = true
+				}
+			}
+		}
+		if len(keys) > 0 {
+			header.Set("Surrogate-Key", strings.Join(keys, " "))
+		}
+	}
+
+	if s, ok := resource.(SurrogateController); ok && header.Get("Surrogate-Control") == "" {
+		if value := s.SurrogateControl().String(); value != "" {
+			header.Set("Surrogate-Control", value)
+		}
+	}
+}
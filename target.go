@@ -0,0 +1,60 @@
+package conditional
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Headers set by reverse proxies describing the original request.
+const (
+	XForwardedHost   = "X-Forwarded-Host"
+	XForwardedPrefix = "X-Forwarded-Prefix"
+	XForwardedProto  = "X-Forwarded-Proto"
+)
+
+// KeyFunc derives the key a resource's validators are stored under.
+type KeyFunc func(c *gin.Context) string
+
+// CanonicalTarget returns the absolute URL the client requested. Behind a
+// path-rewriting proxy the X-Forwarded-* headers are honoured when
+// TrustForwarded is set, so keys do not change with the deployment.
+func (cfg *Config) CanonicalTarget(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	host := r.Host
+	prefix := ""
+
+	if cfg.TrustForwarded {
+		if proto := firstValue(r.Header.Get(XForwardedProto)); proto != "" {
+			scheme = proto
+		}
+		if fwd := firstValue(r.Header.Get(XForwardedHost)); fwd != "" {
+			host = fwd
+		}
+		prefix = strings.TrimSuffix(firstValue(r.Header.Get(XForwardedPrefix)), "/")
+	}
+
+	target := scheme + "://" + strings.ToLower(host) + prefix + r.URL.EscapedPath()
+	if r.URL.RawQuery != "" {
+		target += "?" + r.URL.RawQuery
+	}
+	return target
+}
+
+// TargetKey is a KeyFunc keying resources by their canonical target.
+func (cfg *Config) TargetKey(c *gin.Context) string {
+	return cfg.CanonicalTarget(c.Request)
+}
+
+// Proxies chaining requests append to the X-Forwarded-* headers, the
+// first value is the one the client used.
+func firstValue(value string) string {
+	if i := strings.IndexByte(value, ','); i >= 0 {
+		value = value[:i]
+	}
+	return strings.TrimSpace(value)
+}
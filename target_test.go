@@ -0,0 +1,57 @@
+package conditional
+
+import (
+	"crypto/tls"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestCanonicalTarget(t *testing.T) {
+	forwarded := map[string]string{
+		XForwardedProto:  "https, http",
+		XForwardedHost:   "Example.org, proxy.internal",
+		XForwardedPrefix: "/api/",
+	}
+
+	tests := []struct {
+		name   string
+		cfg    *Config
+		url    string
+		tls    bool
+		header map[string]string
+		want   string
+	}{
+		{"plain", &Config{}, "http://Example.COM/a/b", false, nil, "http://example.com/a/b"},
+		{"tls", &Config{}, "https://example.com/a", true, nil, "https://example.com/a"},
+		{"query", &Config{}, "http://example.com/a?x=1&y=2", false, nil, "http://example.com/a?x=1&y=2"},
+		{"escaped path", &Config{}, "http://example.com/a%20b/c%2Fd", false, nil, "http://example.com/a%20b/c%2Fd"},
+		{"untrusted forwarding", &Config{}, "http://backend:8080/a", false, forwarded, "http://backend:8080/a"},
+		{"trusted forwarding", &Config{TrustForwarded: true}, "http://backend:8080/a", false, forwarded, "https://example.org/api/a"},
+		{"trusted without headers", &Config{TrustForwarded: true}, "http://backend:8080/a", false, nil, "http://backend:8080/a"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(Get, tt.url, nil)
+			if !tt.tls {
+				r.TLS = nil
+			} else if r.TLS == nil {
+				r.TLS = &tls.ConnectionState{}
+			}
+			for name, value := range tt.header {
+				r.Header.Set(name, value)
+			}
+
+			if got := tt.cfg.CanonicalTarget(r); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = r
+			if got := tt.cfg.TargetKey(c); got != tt.want {
+				t.Errorf("TargetKey = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
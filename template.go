@@ -0,0 +1,102 @@
+package conditional
+
+import (
+	"crypto/sha256"
+	"html/template"
+	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/render"
+)
+
+// Templates is a gin HTML renderer that identifies its templates by their
+// source, so rendered pages can be revalidated. Set it as the engine's
+// HTMLRender and render pages with HTML.
+type Templates struct {
+	Template *template.Template
+
+	once   sync.Once
+	digest []byte
+}
+
+// NewTemplates wraps parsed templates. They must not have been executed
+// yet, their source is hashed before html/template escapes it.
+func NewTemplates(t *template.Template) *Templates {
+	ts := &Templates{Template: t}
+	ts.source()
+	return ts
+}
+
+// Returns a digest of the names and source of the templates, the same on
+// every replica parsing the same files.
+func (t *Templates) source() []byte {
+	t.once.Do(func() {
+		templates := t.Template.Templates()
+		sort.Slice(templates, func(i, j int) bool {
+			return templates[i].Name() < templates[j].Name()
+		})
+
+		h := sha256.New()
+		for _, tmpl := range templates {
+			h.Write([]byte(tmpl.Name()))
+			h.Write([]byte{0})
+			if tmpl.Tree != nil && tmpl.Tree.Root != nil {
+				h.Write([]byte(tmpl.Tree.Root.String()))
+			}
+			h.Write([]byte{0})
+		}
+		t.digest = h.Sum(nil)
+	})
+	return t.digest
+}
+
+// Instance implements gin's render.HTMLRender.
+func (t *Templates) Instance(name string, data interface{}) render.Render {
+	return render.HTML{Template: t.Template, Name: name, Data: data}
+}
+
+// HTML renders the template name with data as a 200, answering 304 or 412
+// instead when the request's preconditions allow. The ETag is derived from
+// the template name, the templates' source and the canonical JSON of data.
+// A Last-Modified is only sent when data is a LastModifier, since rendered
+// content changes whenever data does. Data that cannot be encoded as JSON
+// is rendered without validators.
+func HTML(c *gin.Context, t *Templates, name string, data interface{}) {
+	ConfigOf(c).HTML(c, t, name, data)
+}
+
+func (cfg *Config) HTML(c *gin.Context, t *Templates, name string, data interface{}) {
+	if resource, err := cfg.templateResource(t, name, data); err == nil {
+		handled, err := cfg.Conditional(c, resource)
+		if handled {
+			return
+		}
+		if status := errorStatus(err); status != 0 {
+			c.AbortWithStatus(status)
+			return
+		}
+	}
+	c.Render(http.StatusOK, t.Instance(name, data))
+}
+
+func (cfg *Config) templateResource(t *Templates, name string, data interface{}) (interface{}, error) {
+	canonical, err := canonicalJSON(data)
+	if err != nil {
+		return nil, err
+	}
+
+	h := cfg.newHash()
+	h.Write([]byte(name))
+	h.Write([]byte{0})
+	h.Write(t.source())
+	h.Write([]byte{0})
+	h.Write(canonical)
+
+	v := Validators{ETag: cfg.etagFromSum(h.Sum(nil))}
+	if m, ok := data.(LastModifier); ok {
+		v.LastModified = m.LastModified()
+	}
+	return v.Resource(), nil
+}
@@ -0,0 +1,106 @@
+package conditional
+
+import (
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type page struct {
+	Title   string
+	Updated time.Time `json:"-"`
+}
+
+type datedPage page
+
+func (p datedPage) LastModified() time.Time {
+	return p.Updated
+}
+
+const pageSource = `{{define "page"}}<h1>{{.Title}}</h1>{{end}}`
+
+func templateRouter(t *Templates, data func() interface{}) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.HTMLRender = t
+	r.GET("/", func(c *gin.Context) {
+		HTML(c, t, "page", data())
+	})
+	return r
+}
+
+func renderPage(r *gin.Engine, header map[string]string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(Get, "/", nil)
+	for name, value := range header {
+		req.Header.Set(name, value)
+	}
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestHTMLValidators(t *testing.T) {
+	tmpl := NewTemplates(template.Must(template.New("").Parse(pageSource)))
+	data := interface{}(page{Title: "v1"})
+	r := templateRouter(tmpl, func() interface{} { return data })
+
+	w := renderPage(r, nil)
+	etag := w.Header().Get(ETag)
+	if w.Code != http.StatusOK || w.Body.String() != "<h1>v1</h1>" || etag == "" {
+		t.Fatalf("got %d %q with ETag %q", w.Code, w.Body.String(), etag)
+	}
+	if modified := w.Header().Get(LastModified); modified != "" {
+		t.Errorf("Last-Modified %q without a date in data", modified)
+	}
+
+	if w := renderPage(r, map[string]string{IfNoneMatch: etag}); w.Code != http.StatusNotModified {
+		t.Errorf("revalidation got %d, want 304", w.Code)
+	}
+
+	data = page{Title: "v2"}
+	tests := []map[string]string{
+		{IfNoneMatch: etag},
+		{IfModifiedSince: time.Now().Format(http.TimeFormat)},
+	}
+	for _, header := range tests {
+		if w := renderPage(r, header); w.Code != http.StatusOK || w.Body.String() != "<h1>v2</h1>" {
+			t.Errorf("%v after a data change: got %d %q", header, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestHTMLLastModifiedFromData(t *testing.T) {
+	updated := time.Date(2001, 9, 9, 1, 46, 40, 0, time.UTC)
+	tmpl := NewTemplates(template.Must(template.New("").Parse(pageSource)))
+	r := templateRouter(tmpl, func() interface{} { return datedPage{Title: "v1", Updated: updated} })
+
+	w := renderPage(r, nil)
+	if got := w.Header().Get(LastModified); got != updated.Format(http.TimeFormat) {
+		t.Errorf("Last-Modified = %q", got)
+	}
+	if w := renderPage(r, map[string]string{IfModifiedSince: updated.Format(http.TimeFormat)}); w.Code != http.StatusNotModified {
+		t.Errorf("If-Modified-Since got %d, want 304", w.Code)
+	}
+}
+
+func TestHTMLEtagAcrossReplicas(t *testing.T) {
+	data := func() interface{} { return page{Title: "v1"} }
+	etags := map[string]bool{}
+	for _, source := range []string{pageSource, pageSource, `{{define "page"}}<h2>{{.Title}}</h2>{{end}}`} {
+		tmpl := NewTemplates(template.Must(template.New("").Parse(source)))
+		// Rendering escapes the templates, the ETag must not change.
+		r := templateRouter(tmpl, data)
+		first := renderPage(r, nil).Header().Get(ETag)
+		if again := renderPage(r, nil).Header().Get(ETag); again != first {
+			t.Errorf("ETag changed from %s to %s after rendering", first, again)
+		}
+		etags[first] = true
+	}
+	if len(etags) != 2 {
+		t.Errorf("got %d distinct ETags, want one per template source", len(etags))
+	}
+}
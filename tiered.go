@@ -0,0 +1,201 @@
+package conditional
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+)
+
+// TieredContentStore composes ContentStores from fastest to slowest, for
+// example an in-process store over a remote one. Reads go through the
+// tiers in order and backfill the faster tiers on a hit, writes go to
+// every tier.
+type TieredContentStore struct {
+	tiers []ContentStore
+	stats []tierCounters
+
+	// Called when no tier holds the body, the result is written through
+	// to every tier. Optional.
+	Origin func(etag string) (Content, bool)
+
+	originHits   atomic.Uint64
+	originMisses atomic.Uint64
+}
+
+type tierCounters struct {
+	hits, misses atomic.Uint64
+}
+
+// TierStats counts lookups answered, or not, by one tier.
+type TierStats struct {
+	Hits   uint64
+	Misses uint64
+}
+
+func NewTieredContentStore(tiers ...ContentStore) *TieredContentStore {
+	return &TieredContentStore{
+		tiers: tiers,
+		stats: make([]tierCounters, len(tiers)),
+	}
+}
+
+func (s *TieredContentStore) Get(etag string) (Content, bool) {
+	for i, tier := range s.tiers {
+		content, ok := tier.Get(etag)
+		if !ok {
+			s.stats[i].misses.Add(1)
+			continue
+		}
+
+		s.stats[i].hits.Add(1)
+		for _, faster := range s.tiers[:i] {
+			faster.Put(etag, content)
+		}
+		return content, true
+	}
+
+	if s.Origin == nil {
+		return Content{}, false
+	}
+
+	content, ok := s.Origin(etag)
+	if !ok {
+		s.originMisses.Add(1)
+		return Content{}, false
+	}
+
+	s.originHits.Add(1)
+	s.Put(etag, content)
+	return content, true
+}
+
+func (s *TieredContentStore) Put(etag string, content Content) error {
+	for _, tier := range s.tiers {
+		if err := tier.Put(etag, content); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *TieredContentStore) Remove(etag string) {
+	for _, tier := range s.tiers {
+		tier.Remove(etag)
+	}
+}
+
+// Stats returns the counters of each tier in order, followed by the
+// origin's.
+func (s *TieredContentStore) Stats() []TierStats {
+	stats := make([]TierStats, 0, len(s.tiers)+1)
+	for i := range s.stats {
+		stats = append(stats, TierStats{
+			Hits:   s.stats[i].hits.Load(),
+			Misses: s.stats[i].misses.Load(),
+		})
+	}
+	return append(stats, TierStats{
+		Hits:   s.originHits.Load(),
+		Misses: s.originMisses.Load(),
+	})
+}
+
+// TieredValidatorStore composes ValidatorStores from fastest to slowest on
+// the revalidation path, typically memory over Redis over the origin:
+//
+//	store := conditional.NewTieredValidatorStore(
+//		conditional.NewValidatorCache(10000, time.Minute).Store(),
+//		&redisconditional.Store{Client: rdb, Prefix: "validators:"},
+//	)
+//	store.Origin = loadValidators
+//
+// Reads go through the tiers in order and backfill the faster tiers on a
+// hit. A tier that fails is skipped, so an outage only costs a lookup in
+// the next one.
+type TieredValidatorStore struct {
+	tiers []ValidatorStore
+	stats []tierCounters
+
+	// Called when no tier holds the validators of key, the result is
+	// written through to every tier. Optional.
+	Origin func(ctx context.Context, key string) (Validators, bool, error)
+
+	originHits   atomic.Uint64
+	originMisses atomic.Uint64
+}
+
+func NewTieredValidatorStore(tiers ...ValidatorStore) *TieredValidatorStore {
+	return &TieredValidatorStore{
+		tiers: tiers,
+		stats: make([]tierCounters, len(tiers)),
+	}
+}
+
+func (s *TieredValidatorStore) Get(ctx context.Context, key string) (Validators, bool, error) {
+	for i, tier := range s.tiers {
+		v, ok, err := tier.Get(ctx, key)
+		if err != nil || !ok {
+			s.stats[i].misses.Add(1)
+			continue
+		}
+
+		s.stats[i].hits.Add(1)
+		for _, faster := range s.tiers[:i] {
+			faster.Set(ctx, key, v)
+		}
+		return v, true, nil
+	}
+
+	if s.Origin == nil {
+		return Validators{}, false, nil
+	}
+
+	v, ok, err := s.Origin(ctx, key)
+	if err != nil || !ok {
+		s.originMisses.Add(1)
+		return Validators{}, false, err
+	}
+
+	s.originHits.Add(1)
+	s.Set(ctx, key, v)
+	return v, true, nil
+}
+
+// Set writes v to every tier, returning their errors joined.
+func (s *TieredValidatorStore) Set(ctx context.Context, key string, v Validators) error {
+	var errs []error
+	for _, tier := range s.tiers {
+		if err := tier.Set(ctx, key, v); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Invalidate drops key from the slowest tier first, so a concurrent read
+// cannot backfill a faster tier with the old validators once it is done.
+func (s *TieredValidatorStore) Invalidate(ctx context.Context, key string) error {
+	var errs []error
+	for i := len(s.tiers) - 1; i >= 0; i-- {
+		if err := s.tiers[i].Invalidate(ctx, key); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Stats returns the counters of each tier in order, followed by the
+// origin's.
+func (s *TieredValidatorStore) Stats() []TierStats {
+	stats := make([]TierStats, 0, len(s.tiers)+1)
+	for i := range s.stats {
+		stats = append(stats, TierStats{
+			Hits:   s.stats[i].hits.Load(),
+			Misses: s.stats[i].misses.Load(),
+		})
+	}
+	return append(stats, TierStats{
+		Hits:   s.originHits.Load(),
+		Misses: s.originMisses.Load(),
+	})
+}
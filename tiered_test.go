@@ -0,0 +1,96 @@
+package conditional
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type failingStore struct{}
+
+var errStoreDown = errors.New("store down")
+
+func (failingStore) Get(ctx context.Context, key string) (Validators, bool, error) {
+	return Validators{}, false, errStoreDown
+}
+
+func (failingStore) Set(ctx context.Context, key string, v Validators) error {
+	return errStoreDown
+}
+
+func (failingStore) Invalidate(ctx context.Context, key string) error {
+	return errStoreDown
+}
+
+func TestTieredValidatorStore(t *testing.T) {
+	ctx := context.Background()
+	memory, remote := NewValidatorCache(0, 0), NewValidatorCache(0, 0)
+	store := NewTieredValidatorStore(memory.Store(), remote.Store())
+	origin := 0
+	store.Origin = func(ctx context.Context, key string) (Validators, bool, error) {
+		origin++
+		return Validators{ETag: `"` + key + `"`}, key != "missing", nil
+	}
+
+	// From the origin, written through.
+	if v, ok, _ := store.Get(ctx, "a"); !ok || v.ETag != `"a"` {
+		t.Fatalf("Get(a) = %v, %v", v, ok)
+	}
+	if _, ok := remote.Get("a"); !ok {
+		t.Error("origin result not written to the remote tier")
+	}
+
+	// From the remote tier, backfilling memory.
+	remote.Set("b", Validators{ETag: `"b"`})
+	if v, ok, _ := store.Get(ctx, "b"); !ok || v.ETag != `"b"` {
+		t.Fatalf("Get(b) = %v, %v", v, ok)
+	}
+	if _, ok := memory.Get("b"); !ok {
+		t.Error("remote hit not backfilled into memory")
+	}
+	store.Get(ctx, "b")
+
+	if _, ok, _ := store.Get(ctx, "missing"); ok {
+		t.Error("missing key found")
+	}
+	if origin != 2 {
+		t.Errorf("origin called %d times, want 2", origin)
+	}
+
+	want := []TierStats{{Hits: 1, Misses: 3}, {Hits: 1, Misses: 2}, {Hits: 1, Misses: 1}}
+	if got := store.Stats(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Stats() = %v, want %v", got, want)
+	}
+
+	store.Invalidate(ctx, "b")
+	if _, ok := memory.Get("b"); ok {
+		t.Error("b still in memory after Invalidate")
+	}
+	if _, ok := remote.Get("b"); ok {
+		t.Error("b still in the remote tier after Invalidate")
+	}
+}
+
+func TestTieredValidatorStoreSkipsFailingTier(t *testing.T) {
+	ctx := context.Background()
+	memory := NewValidatorCache(0, 0)
+	store := NewTieredValidatorStore(memory.Store(), failingStore{})
+	store.Origin = func(ctx context.Context, key string) (Validators, bool, error) {
+		return Validators{LastModified: time.Unix(1e9, 0)}, true, nil
+	}
+
+	if v, ok, err := store.Get(ctx, "a"); err != nil || !ok || v.LastModified.IsZero() {
+		t.Fatalf("Get(a) = %v, %v, %v", v, ok, err)
+	}
+	if _, ok := memory.Get("a"); !ok {
+		t.Error("origin result not kept in memory")
+	}
+	if err := store.Invalidate(ctx, "a"); !errors.Is(err, errStoreDown) {
+		t.Errorf("Invalidate = %v, want the failing tier's error", err)
+	}
+	if _, ok := memory.Get("a"); ok {
+		t.Error("memory tier not invalidated past the failing one")
+	}
+}
@@ -0,0 +1,55 @@
+package conditional
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const validatorTimeKey = "conditional.validator_time"
+
+// Wraps the validators of an evaluation so the time spent in them is
+// added to the request's total, when the Config asks for it.
+func (cfg *Config) timed(c *gin.Context, etagger Etagger, modifier LastModifier) (Etagger, LastModifier) {
+	if !cfg.TimeValidators {
+		return etagger, modifier
+	}
+	if etagger != nil {
+		etagger = timedEtagger{etagger, c}
+	}
+	if modifier != nil {
+		modifier = timedModifier{modifier, c}
+	}
+	return etagger, modifier
+}
+
+// Time taken by the resource's Etag and LastModified so far.
+func validatorTime(c *gin.Context) time.Duration {
+	d, _ := c.Get(validatorTimeKey)
+	total, _ := d.(time.Duration)
+	return total
+}
+
+func addValidatorTime(c *gin.Context, start time.Time) {
+	c.Set(validatorTimeKey, validatorTime(c)+time.Since(start))
+}
+
+type timedEtagger struct {
+	Etagger
+	c *gin.Context
+}
+
+func (t timedEtagger) Etag() (string, error) {
+	defer addValidatorTime(t.c, time.Now())
+	return t.Etagger.Etag()
+}
+
+type timedModifier struct {
+	LastModifier
+	c *gin.Context
+}
+
+func (t timedModifier) LastModified() time.Time {
+	defer addValidatorTime(t.c, time.Now())
+	return t.LastModifier.LastModified()
+}
@@ -0,0 +1,106 @@
+package conditional
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Binding evaluates values of a concrete type through validator functions
+// bound once, so the type need not implement Etagger or LastModifier:
+//
+//	var articles = conditional.Bind(
+//		func(a *Article) (string, error) { return a.ETag, nil },
+//		func(a *Article) time.Time { return a.Updated },
+//	)
+//
+//	handled, err := articles.Conditional(c, article)
+//
+// Conditional reads the validators of v directly and compares them
+// without converting v to an interface, so evaluating allocates nothing
+// beyond the response headers. Each call reads them again, they are not
+// kept for the rest of the request as a resource's are.
+//
+// Either function may be nil when the type has no such validator.
+type Binding[T any] struct {
+	etag         func(T) (string, error)
+	lastModified func(T) time.Time
+}
+
+func Bind[T any](etag func(T) (string, error), lastModified func(T) time.Time) *Binding[T] {
+	return &Binding[T]{etag: etag, lastModified: lastModified}
+}
+
+// Conditional evaluates the request's preconditions against v, see the
+// package's Conditional.
+func (b *Binding[T]) Conditional(c *gin.Context, v T) (bool, error) {
+	cfg := ConfigOf(c)
+	var etag, modified memo
+	return cfg.conditional(c, nil, b.resolve(c, cfg, v, &etag, &modified))
+}
+
+// Reads the validators of v into etag and modified.
+func (b *Binding[T]) resolve(c *gin.Context, cfg *Config, v T, etag, modified *memo) resolved {
+	var r resolved
+	start := time.Now()
+	if b.etag != nil {
+		etag.etag, etag.etagErr = b.etag(v)
+		etag.etagDone = true
+		r.etag = etag
+	}
+	if b.lastModified != nil {
+		modified.lastModified = b.lastModified(v)
+		modified.lastModifiedDone = true
+		r.modified = modified
+	}
+	if cfg.TimeValidators {
+		addValidatorTime(c, start)
+	}
+	return r
+}
+
+// Resource returns v as a resource for the package's other functions.
+// Resources of the same v share their memo for the request when T is
+// comparable.
+func (b *Binding[T]) Resource(v T) interface{} {
+	switch {
+	case b.etag != nil && b.lastModified != nil:
+		return boundValidators[T]{b, v}
+	case b.etag != nil:
+		return boundEtag[T]{b, v}
+	case b.lastModified != nil:
+		return boundLastModified[T]{b, v}
+	}
+	return nil
+}
+
+type boundEtag[T any] struct {
+	binding *Binding[T]
+	value   T
+}
+
+func (b boundEtag[T]) Etag() (string, error) {
+	return b.binding.etag(b.value)
+}
+
+type boundLastModified[T any] struct {
+	binding *Binding[T]
+	value   T
+}
+
+func (b boundLastModified[T]) LastModified() time.Time {
+	return b.binding.lastModified(b.value)
+}
+
+type boundValidators[T any] struct {
+	binding *Binding[T]
+	value   T
+}
+
+func (b boundValidators[T]) Etag() (string, error) {
+	return b.binding.etag(b.value)
+}
+
+func (b boundValidators[T]) LastModified() time.Time {
+	return b.binding.lastModified(b.value)
+}
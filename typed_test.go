@@ -0,0 +1,113 @@
+package conditional
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type article struct {
+	etag    string
+	updated time.Time
+}
+
+var articles = Bind(
+	func(a *article) (string, error) { return a.etag, nil },
+	func(a *article) time.Time { return a.updated },
+)
+
+func articleContext(method string, header http.Header) *gin.Context {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(method, "/", nil)
+	c.Request.Header = header
+	return c
+}
+
+func TestBindingConditional(t *testing.T) {
+	a := &article{etag: `"a"`, updated: time.Unix(1e9, 0)}
+	for _, test := range []struct {
+		method string
+		header string
+		value  string
+		status int
+	}{
+		{Get, IfNoneMatch, `"a"`, http.StatusNotModified},
+		{Get, IfNoneMatch, `"b"`, 0},
+		{Put, IfMatch, `"b"`, http.StatusPreconditionFailed},
+		{Get, IfModifiedSince, "Sun, 09 Sep 2001 01:46:40 GMT", http.StatusNotModified},
+	} {
+		c := articleContext(test.method, http.Header{test.header: {test.value}})
+		handled, err := articles.Conditional(c, a)
+		status := StatusOf(err)
+		if handled {
+			status = c.Writer.Status()
+		}
+		if status != test.status {
+			t.Errorf("%s %s: %s, status = %d, want %d", test.method, test.header, test.value, status, test.status)
+		}
+	}
+}
+
+func TestBindingResourceShareMemo(t *testing.T) {
+	calls := 0
+	b := Bind(func(a *article) (string, error) { calls++; return a.etag, nil }, nil)
+	c := articleContext(Get, http.Header{})
+	a := &article{etag: `"a"`}
+	ValidatorsOf(c, b.Resource(a))
+	ValidatorsOf(c, b.Resource(a))
+	if calls != 1 {
+		t.Errorf("Etag called %d times, want 1", calls)
+	}
+}
+
+func TestBindingEvaluateAllocs(t *testing.T) {
+	a := &article{etag: `"a"`, updated: time.Unix(1e9, 0)}
+	c := articleContext(Put, http.Header{IfMatch: {`"x", "a"`}, IfUnmodifiedSince: {"Sun, 09 Sep 2001 01:46:40 GMT"}})
+	cfg := ConfigOf(c)
+	allocs := testing.AllocsPerRun(100, func() {
+		var etag, modified memo
+		var e Evaluation
+		cfg.evaluate(c, nil, articles.resolve(c, cfg, a, &etag, &modified), &e)
+	})
+	if allocs != 0 {
+		t.Errorf("evaluate allocated %v times, want 0", allocs)
+	}
+}
+
+func BenchmarkBindingEvaluate(b *testing.B) {
+	a := &article{etag: `"a"`, updated: time.Unix(1e9, 0)}
+	c := articleContext(Get, http.Header{IfNoneMatch: {`"x", "a"`}})
+	cfg := ConfigOf(c)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var etag, modified memo
+		var e Evaluation
+		cfg.evaluate(c, nil, articles.resolve(c, cfg, a, &etag, &modified), &e)
+	}
+}
+
+func BenchmarkBindingConditional(b *testing.B) {
+	a := &article{etag: `"a"`, updated: time.Unix(1e9, 0)}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		c := articleContext(Put, http.Header{IfMatch: {`"a"`}})
+		b.StartTimer()
+		articles.Conditional(c, a)
+	}
+}
+
+func BenchmarkResourceConditional(b *testing.B) {
+	a := &article{etag: `"a"`, updated: time.Unix(1e9, 0)}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		c := articleContext(Put, http.Header{IfMatch: {`"a"`}})
+		b.StartTimer()
+		Conditional(c, articles.Resource(a))
+	}
+}
@@ -0,0 +1,169 @@
+package conditional
+
+import (
+	"hash/maphash"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// ValidatorCache keeps the Validators computed for resource keys, so
+// content hashes and database lookups are not repeated on every GET. It
+// holds at most capacity keys, evicting the least recently used unless
+// another policy is given to NewShardedValidatorCache, and entries expire after a TTL. Call Invalidate after writes, or list the
+// cache in Config.Invalidators to have GuardBuilder routes do it:
+//
+//	cache := conditional.NewValidatorCache(10000, time.Minute)
+//	resource, err := cache.Resource(key, func() (conditional.Validators, error) {
+//		return loadValidators(key)
+//	})
+//
+// Keys are spread over shards with a lock each, so services with many
+// distinct resources do not serialize on one mutex. Recency and capacity
+// are per shard, which makes eviction approximate.
+type ValidatorCache struct {
+	shards []validatorShard
+	seed   maphash.Seed
+	ttl    time.Duration
+
+	// Replaces time.Now for expiry.
+	Clock func() time.Time
+}
+
+type validatorShard struct {
+	mu       sync.Mutex
+	entries  map[string]cachedValidators
+	capacity int
+	policy   EvictionPolicy
+}
+
+type cachedValidators struct {
+	validators Validators
+	expires    time.Time
+}
+
+// Keys a shard is meant to hold at least, for LRU order to be useful.
+const minShardKeys = 64
+
+// NewValidatorCache returns a cache of at most capacity keys, unbounded
+// when zero, whose entries expire after ttl, or never when zero. It is
+// sharded by GOMAXPROCS.
+func NewValidatorCache(capacity int, ttl time.Duration) *ValidatorCache {
+	shards := 1
+	for shards < 4*runtime.GOMAXPROCS(0) && shards < 256 &&
+		(capacity == 0 || capacity/(2*shards) >= minShardKeys) {
+		shards *= 2
+	}
+	return NewShardedValidatorCache(shards, capacity, ttl, nil)
+}
+
+// NewShardedValidatorCache is NewValidatorCache with the number of shards
+// set explicitly, rounded up to a power of two, and each shard evicting by
+// the policy newPolicy makes, LRU when nil.
+func NewShardedValidatorCache(shards, capacity int, ttl time.Duration, newPolicy PolicyFactory) *ValidatorCache {
+	if newPolicy == nil {
+		newPolicy = LRU
+	}
+	n := 1
+	for n < shards {
+		n *= 2
+	}
+
+	vc := &ValidatorCache{shards: make([]validatorShard, n), seed: maphash.MakeSeed(), ttl: ttl}
+	for i := range vc.shards {
+		vc.shards[i].entries = make(map[string]cachedValidators)
+		if capacity > 0 {
+			vc.shards[i].capacity = (capacity + n - 1) / n
+		}
+		vc.shards[i].policy = newPolicy(vc.shards[i].capacity)
+	}
+	return vc
+}
+
+func (vc *ValidatorCache) shard(key string) *validatorShard {
+	return &vc.shards[maphash.String(vc.seed, key)&uint64(len(vc.shards)-1)]
+}
+
+func (vc *ValidatorCache) now() time.Time {
+	if vc.Clock != nil {
+		return vc.Clock()
+	}
+	return time.Now()
+}
+
+// Get returns the cached validators of key, false when absent or expired.
+func (vc *ValidatorCache) Get(key string) (Validators, bool) {
+	s := vc.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[key]
+	if !ok {
+		return Validators{}, false
+	}
+	if !entry.expires.IsZero() && !vc.now().Before(entry.expires) {
+		s.remove(key)
+		return Validators{}, false
+	}
+	s.policy.Accessed(key)
+	return entry.validators, true
+}
+
+// Set caches the validators of key for the TTL.
+func (vc *ValidatorCache) Set(key string, v Validators) {
+	entry := cachedValidators{validators: v}
+	if vc.ttl > 0 {
+		entry.expires = vc.now().Add(vc.ttl)
+	}
+
+	s := vc.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.entries[key]; !ok && s.capacity > 0 {
+		for len(s.entries) >= s.capacity {
+			victim, ok := s.policy.Victim()
+			if !ok {
+				break
+			}
+			delete(s.entries, victim)
+		}
+	}
+	s.policy.Added(key)
+	s.entries[key] = entry
+}
+
+// Invalidate drops the validators of key, the next lookup recomputes them.
+func (vc *ValidatorCache) Invalidate(key string) {
+	s := vc.shard(key)
+	s.mu.Lock()
+	s.remove(key)
+	s.mu.Unlock()
+}
+
+// OnResourceChanged implements Invalidator.
+func (vc *ValidatorCache) OnResourceChanged(key string) {
+	vc.Invalidate(key)
+}
+
+func (s *validatorShard) remove(key string) {
+	if _, ok := s.entries[key]; ok {
+		delete(s.entries, key)
+		s.policy.Removed(key)
+	}
+}
+
+// Resource returns the validators of key as a resource for Conditional,
+// calling compute and caching its result on a miss. Errors are returned
+// without being cached.
+func (vc *ValidatorCache) Resource(key string, compute func() (Validators, error)) (interface{}, error) {
+	v, ok := vc.Get(key)
+	if !ok {
+		var err error
+		if v, err = compute(); err != nil {
+			return nil, err
+		}
+		vc.Set(key, v)
+	}
+	return v.Resource(), nil
+}
@@ -0,0 +1,166 @@
+package conditional
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestValidatorCacheShards(t *testing.T) {
+	for _, test := range []struct {
+		shards, want int
+	}{
+		{1, 1}, {3, 4}, {8, 8}, {9, 16},
+	} {
+		if got := len(NewShardedValidatorCache(test.shards, 0, 0, nil).shards); got != test.want {
+			t.Errorf("%d shards: got %d, want %d", test.shards, got, test.want)
+		}
+	}
+
+	// Small caches keep enough keys per shard for the LRU order to mean
+	// something.
+	if got := len(NewValidatorCache(100, 0).shards); got != 1 {
+		t.Errorf("capacity 100: %d shards, want 1", got)
+	}
+}
+
+func TestValidatorCacheShardSelection(t *testing.T) {
+	vc := NewShardedValidatorCache(8, 0, 0, nil)
+	used := map[*validatorShard]bool{}
+	for i := 0; i < 1000; i++ {
+		key := strconv.Itoa(i)
+		s := vc.shard(key)
+		if s != vc.shard(key) {
+			t.Fatalf("key %q moved between shards", key)
+		}
+		used[s] = true
+		vc.Set(key, Validators{ETag: `"` + key + `"`})
+	}
+	if len(used) != 8 {
+		t.Errorf("keys spread over %d of 8 shards", len(used))
+	}
+	for i := 0; i < 1000; i++ {
+		key := strconv.Itoa(i)
+		if v, ok := vc.Get(key); !ok || v.ETag != `"`+key+`"` {
+			t.Fatalf("Get(%q) = %v, %v", key, v, ok)
+		}
+	}
+}
+
+func TestValidatorCacheEvictsLeastRecent(t *testing.T) {
+	vc := NewShardedValidatorCache(1, 2, 0, nil)
+	vc.Set("a", Validators{ETag: `"a"`})
+	vc.Set("b", Validators{ETag: `"b"`})
+	vc.Get("a")
+	vc.Set("c", Validators{ETag: `"c"`})
+
+	if _, ok := vc.Get("b"); ok {
+		t.Error("b was kept, want it evicted")
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, ok := vc.Get(key); !ok {
+			t.Errorf("%s was evicted", key)
+		}
+	}
+}
+
+func TestValidatorCachePolicy(t *testing.T) {
+	vc := NewShardedValidatorCache(1, 2, 0, LFU)
+	vc.Set("a", Validators{ETag: `"a"`})
+	vc.Get("a")
+	vc.Set("b", Validators{ETag: `"b"`})
+	vc.Set("c", Validators{ETag: `"c"`})
+
+	// LRU would evict "a", read before "b" was set.
+	if _, ok := vc.Get("b"); ok {
+		t.Error("b was kept, want it evicted as least frequently used")
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, ok := vc.Get(key); !ok {
+			t.Errorf("%s was evicted", key)
+		}
+	}
+
+	var capacities []int
+	NewShardedValidatorCache(4, 100, 0, func(capacity int) EvictionPolicy {
+		capacities = append(capacities, capacity)
+		return NewLRU()
+	})
+	if len(capacities) != 4 || capacities[0] != 25 {
+		t.Errorf("policies made for capacities %v, want 4 of 25", capacities)
+	}
+}
+
+func TestValidatorCacheCapacityPerShard(t *testing.T) {
+	vc := NewShardedValidatorCache(4, 100, 0, nil)
+	for i := 0; i < 1000; i++ {
+		vc.Set(strconv.Itoa(i), Validators{})
+	}
+	for i := range vc.shards {
+		if n := len(vc.shards[i].entries); n > 25 {
+			t.Errorf("shard %d holds %d keys, want at most 25", i, n)
+		}
+	}
+}
+
+func TestValidatorCacheExpiry(t *testing.T) {
+	now := time.Unix(0, 0)
+	vc := NewValidatorCache(0, time.Minute)
+	vc.Clock = func() time.Time { return now }
+	vc.Set("a", Validators{ETag: `"a"`})
+
+	now = now.Add(59 * time.Second)
+	if _, ok := vc.Get("a"); !ok {
+		t.Error("entry expired early")
+	}
+	now = now.Add(time.Second)
+	if _, ok := vc.Get("a"); ok {
+		t.Error("entry did not expire")
+	}
+}
+
+func TestValidatorCacheInvalidate(t *testing.T) {
+	vc := NewValidatorCache(10, 0)
+	vc.Set("a", Validators{ETag: `"a"`})
+	vc.OnResourceChanged("a")
+	if _, ok := vc.Get("a"); ok {
+		t.Error("invalidated entry still cached")
+	}
+}
+
+// Run with -cpu 1,2,4,8 to see lookups scale with the shards.
+func BenchmarkValidatorCache(b *testing.B) {
+	const keys = 1 << 14
+	names := make([]string, keys)
+	for i := range names {
+		names[i] = "/articles/" + strconv.Itoa(i)
+	}
+
+	for _, shards := range []int{1, 0} {
+		name := "sharded"
+		vc := NewValidatorCache(keys, time.Minute)
+		if shards == 1 {
+			name = "single"
+			vc = NewShardedValidatorCache(1, keys, time.Minute, nil)
+		}
+		for _, key := range names {
+			vc.Set(key, Validators{ETag: `"x"`})
+		}
+
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			b.RunParallel(func(pb *testing.PB) {
+				i := 0
+				for pb.Next() {
+					key := names[i&(keys-1)]
+					if i%16 == 0 {
+						vc.Set(key, Validators{ETag: `"y"`})
+					} else {
+						vc.Get(key)
+					}
+					i += 7
+				}
+			})
+		})
+	}
+}
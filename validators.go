@@ -0,0 +1,76 @@
+package conditional
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Validators are a resource's ETag and Last-Modified, as kept by stores
+// and services that evaluate preconditions without the resource itself.
+// Either may be empty.
+type Validators struct {
+	ETag         string    `json:"etag,omitempty"`
+	LastModified time.Time `json:"last_modified,omitempty"`
+}
+
+// Resource returns a value implementing Etagger, LastModifier or both,
+// depending on which validators are set, for use with Conditional.
+func (v Validators) Resource() interface{} {
+	switch {
+	case v.ETag != "" && !v.LastModified.IsZero():
+		return validatorsResource{v.ETag, v.LastModified}
+	case v.ETag != "":
+		return etagValue(v.ETag)
+	case !v.LastModified.IsZero():
+		return lastModifiedValue(v.LastModified)
+	}
+	return nil
+}
+
+type validatorsResource struct {
+	etag         string
+	lastModified time.Time
+}
+
+func (v validatorsResource) Etag() (string, error) {
+	return v.etag, nil
+}
+
+func (v validatorsResource) LastModified() time.Time {
+	return v.lastModified
+}
+
+// lastModifiedValue is a LastModifier for an already known date.
+type lastModifiedValue time.Time
+
+func (l lastModifiedValue) LastModified() time.Time {
+	return time.Time(l)
+}
+
+// Sets the ETag and Last-Modified response headers from r, the validators
+// of resource, unless the handler already set them, and mirrors them into
+// aliased headers. Revalidated resources get a Cache-Control forcing
+// revalidation. Under OmitValidators only headers the handler set are
+// mirrored.
+func (cfg *Config) setValidators(c *gin.Context, resource interface{}, r resolved) {
+	header := c.Writer.Header()
+
+	if r.etag != nil && header.Get(ETag) == "" && !cfg.OmitValidators {
+		if etag, err := r.etag.Etag(); err == nil && etag != "" {
+			header.Set(ETag, etag)
+		}
+	}
+
+	if r.modified != nil && header.Get(LastModified) == "" && !cfg.OmitValidators {
+		if t := r.modified.LastModified(); !t.IsZero() {
+			header.Set(LastModified, t.UTC().Format(http.TimeFormat))
+		}
+	}
+
+	if header.Get(ETag) != "" || header.Get(LastModified) != "" {
+		enforceRevalidation(header, resource)
+	}
+	cfg.mirror(header)
+}
@@ -0,0 +1,158 @@
+package conditional
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// The gin.Context key the negotiated Variant is stored under.
+const VariantKey = "conditional.variant"
+
+// Set when Negotiate already added the dimensions it chose from to Vary.
+const negotiatedKey = "conditional.negotiated"
+
+// Variant identifies one representation of a resource chosen through
+// content negotiation.
+type Variant struct {
+	MediaType string
+	Encoding  string
+	Language  string
+}
+
+// VariantEtagger can be implemented by resources with several
+// representations, so that, for example, the JSON and XML forms of the
+// same resource don't share an ETag. The evaluator uses the Variant
+// stored with SetVariant or Negotiate.
+type VariantEtagger interface {
+	EtagFor(variant Variant) (string, error)
+}
+
+// Offers lists the representations a handler can produce, for Negotiate.
+type Offers struct {
+	MediaTypes []string
+	Encodings  []string
+	Languages  []string
+}
+
+// SetVariant records the representation the handler will send.
+func SetVariant(c *gin.Context, variant Variant) {
+	c.Set(VariantKey, variant)
+}
+
+// VariantOf returns the representation recorded for the request.
+func VariantOf(c *gin.Context) Variant {
+	variant, _ := c.Get(VariantKey)
+	v, _ := variant.(Variant)
+	return v
+}
+
+// Negotiate picks the best offered representation from the request's
+// Accept, Accept-Encoding and Accept-Language headers, records it with
+// SetVariant and adds the dimensions with a choice to Vary.
+func Negotiate(c *gin.Context, offers Offers) Variant {
+	r := c.Request
+	variant := Variant{
+		MediaType: negotiate(r.Header.Get("Accept"), offers.MediaTypes),
+		Encoding:  negotiate(r.Header.Get("Accept-Encoding"), offers.Encodings),
+		Language:  negotiate(r.Header.Get("Accept-Language"), offers.Languages),
+	}
+
+	header := c.Writer.Header()
+	if len(offers.MediaTypes) > 1 {
+		addVary(header, "Accept")
+	}
+	if len(offers.Encodings) > 1 {
+		addVary(header, "Accept-Encoding")
+	}
+	if len(offers.Languages) > 1 {
+		addVary(header, "Accept-Language")
+	}
+
+	SetVariant(c, variant)
+	c.Set(negotiatedKey, true)
+	return variant
+}
+
+// Returns resource's Etagger, binding a VariantEtagger to the request's
+// Variant when the resource has no plain Etag.
+func asEtagger(c *gin.Context, resource interface{}) (Etagger, bool) {
+	if e, ok := resource.(Etagger); ok {
+		return e, true
+	}
+	if v, ok := resource.(VariantEtagger); ok {
+		return boundVariant{v, VariantOf(c)}, true
+	}
+	return nil, false
+}
+
+type boundVariant struct {
+	resource VariantEtagger
+	variant  Variant
+}
+
+func (b boundVariant) Etag() (string, error) {
+	return b.resource.EtagFor(b.variant)
+}
+
+type acceptItem struct {
+	value string
+	q     float64
+}
+
+// Picks the offer with the highest q-value in an Accept style header,
+// each offer taking the q-value of the most specific range matching it, so
+// "application/json;q=0, */*" refuses JSON. Earlier offers are preferred
+// on ties. The first offer is the default when the header is absent or
+// nothing matches.
+func negotiate(header string, offers []string) string {
+	if len(offers) == 0 {
+		return ""
+	}
+	if header == "" {
+		return offers[0]
+	}
+
+	var accepted []acceptItem
+	for _, part := range strings.Split(header, ",") {
+		value, params, _ := strings.Cut(part, ";")
+		item := acceptItem{value: strings.ToLower(strings.TrimSpace(value)), q: 1}
+		for _, param := range strings.Split(params, ";") {
+			if k, v, ok := strings.Cut(strings.TrimSpace(param), "="); ok && k == "q" {
+				if q, err := strconv.ParseFloat(v, 64); err == nil {
+					item.q = q
+				}
+			}
+		}
+		accepted = append(accepted, item)
+	}
+
+	best, bestQ := offers[0], 0.0
+	for _, offer := range offers {
+		q, specificity := 0.0, -1
+		for _, item := range accepted {
+			if s := acceptSpecificity(item.value, strings.ToLower(offer)); s > specificity {
+				q, specificity = item.q, s
+			}
+		}
+		if q > bestQ {
+			best, bestQ = offer, q
+		}
+	}
+	return best
+}
+
+// Matches "*", "type/*" and language prefixes like "en" for "en-US",
+// returning how specific the match is, or -1 when pattern does not match.
+func acceptSpecificity(pattern, offer string) int {
+	switch {
+	case pattern == "*", pattern == "*/*":
+		return 0
+	case pattern == offer,
+		strings.HasSuffix(pattern, "/*") && strings.HasPrefix(offer, pattern[:len(pattern)-1]),
+		strings.HasPrefix(offer, pattern+"-"):
+		return len(pattern)
+	}
+	return -1
+}
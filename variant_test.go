@@ -0,0 +1,117 @@
+package conditional
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestNegotiate(t *testing.T) {
+	offers := []string{"application/json", "application/xml", "text/html"}
+
+	tests := []struct {
+		name   string
+		header string
+		offers []string
+		want   string
+	}{
+		{"no header", "", offers, "application/json"},
+		{"no offers", "text/html", nil, ""},
+		{"exact", "application/xml", offers, "application/xml"},
+		{"case insensitive", "Application/XML", offers, "application/xml"},
+		{"q-values", "application/json;q=0.5, text/html", offers, "text/html"},
+		{"offer order on ties", "text/html, application/xml", offers, "application/xml"},
+		{"most specific range", "text/*;q=0.2, text/html;q=0.8, */*;q=0.5", offers, "text/html"},
+		{"type wildcard", "text/*", offers, "text/html"},
+		{"any", "*/*", offers, "application/json"},
+		{"refused", "application/json;q=0, */*;q=0.1", offers, "application/xml"},
+		{"nothing acceptable", "image/png", offers, "application/json"},
+		{"malformed q", "application/xml;q=high", offers, "application/xml"},
+		{"language prefix", "en", []string{"fr", "en-US"}, "en-US"},
+		{"language refused", "en-gb;q=0, en", []string{"en-GB", "en-US"}, "en-US"},
+		{"language not a prefix", "en", []string{"fr", "eng"}, "fr"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := negotiate(tt.header, tt.offers); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// variantDoc has one ETag per media type it is rendered to.
+type variantDoc struct{}
+
+func (variantDoc) EtagFor(v Variant) (string, error) {
+	return `"doc-` + v.MediaType + `"`, nil
+}
+
+func TestVariantEtags(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/negotiated", func(c *gin.Context) {
+		Negotiate(c, Offers{MediaTypes: []string{"application/json", "application/xml"}, Languages: []string{"en"}})
+		if handled, _ := Conditional(c, variantDoc{}); !handled {
+			c.Status(http.StatusOK)
+		}
+	})
+	r.GET("/set", func(c *gin.Context) {
+		SetVariant(c, Variant{MediaType: "application/xml", Language: "en"})
+		if handled, _ := Conditional(c, variantDoc{}); !handled {
+			c.Status(http.StatusOK)
+		}
+	})
+
+	tests := []struct {
+		name   string
+		path   string
+		header map[string]string
+		status int
+		etag   string
+		vary   []string
+	}{
+		{"default variant", "/negotiated", nil, http.StatusOK, `"doc-application/json"`, []string{"Accept"}},
+		{"xml variant", "/negotiated", map[string]string{"Accept": "application/xml"}, http.StatusOK, `"doc-application/xml"`, []string{"Accept"}},
+		{"revalidated", "/negotiated", map[string]string{"Accept": "application/xml", IfNoneMatch: `"doc-application/xml"`}, http.StatusNotModified, `"doc-application/xml"`, []string{"Accept"}},
+		{"other variant's etag", "/negotiated", map[string]string{IfNoneMatch: `"doc-application/xml"`}, http.StatusOK, `"doc-application/json"`, []string{"Accept"}},
+		{"set variant", "/set", nil, http.StatusOK, `"doc-application/xml"`, []string{"Accept", "Accept-Language"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := request(r, Get, tt.path, tt.header)
+			if w.Code != tt.status || w.Header().Get(ETag) != tt.etag {
+				t.Errorf("got %d with ETag %q, want %d with %q", w.Code, w.Header().Get(ETag), tt.status, tt.etag)
+			}
+			if got := w.Header().Values("Vary"); !sameValues(got, tt.vary) {
+				t.Errorf("Vary = %q, want %q", got, tt.vary)
+			}
+		})
+	}
+}
+
+// Compares header values, which addVary may join on one line.
+func sameValues(got, want []string) bool {
+	h := http.Header{}
+	for _, v := range got {
+		addVary(h, v)
+	}
+	w := http.Header{}
+	for _, v := range want {
+		addVary(w, v)
+	}
+	return h.Get("Vary") == w.Get("Vary")
+}
+
+func TestVariantOf(t *testing.T) {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	if v := VariantOf(c); v != (Variant{}) {
+		t.Errorf("VariantOf without a variant = %+v", v)
+	}
+	SetVariant(c, Variant{Encoding: "gzip"})
+	if v := VariantOf(c); v.Encoding != "gzip" {
+		t.Errorf("VariantOf = %+v", v)
+	}
+}
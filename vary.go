@@ -0,0 +1,59 @@
+package conditional
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AddVary adds fields to the Vary header of c's response, unless already
+// listed or Vary is "*". The package adds Accept-Encoding itself for
+// encoded ETags, and the negotiated dimensions for variant ETags.
+func AddVary(c *gin.Context, fields ...string) {
+	addVary(c.Writer.Header(), fields...)
+}
+
+// Adds fields to the Vary header unless already listed.
+func addVary(header http.Header, fields ...string) {
+	present := map[string]bool{}
+	for _, value := range header.Values("Vary") {
+		for _, field := range strings.Split(value, ",") {
+			present[strings.ToLower(strings.TrimSpace(field))] = true
+		}
+	}
+	if present["*"] {
+		return
+	}
+
+	for _, field := range fields {
+		if !present[strings.ToLower(field)] {
+			header.Add("Vary", field)
+			present[strings.ToLower(field)] = true
+		}
+	}
+}
+
+// A VariantEtagger's ETag depends on the request headers its Variant was
+// chosen from, so caches must key on them. Variants recorded with
+// SetVariant, rather than Negotiate, get Vary for every dimension set.
+func varyVariant(c *gin.Context, resource interface{}) {
+	if _, ok := resource.(Etagger); ok {
+		return
+	}
+	if _, ok := resource.(VariantEtagger); !ok || c.GetBool(negotiatedKey) {
+		return
+	}
+
+	header := c.Writer.Header()
+	variant := VariantOf(c)
+	if variant.MediaType != "" {
+		addVary(header, "Accept")
+	}
+	if variant.Encoding != "" {
+		addVary(header, "Accept-Encoding")
+	}
+	if variant.Language != "" {
+		addVary(header, "Accept-Language")
+	}
+}
@@ -0,0 +1,87 @@
+package conditional
+
+import (
+	"io/fs"
+	"strings"
+	"time"
+)
+
+// Poll makes StaticFS re-stat the files whose content ETags it caches
+// every interval, dropping the entries of files that changed or were
+// removed, so the memory of deleted files is reclaimed even when they are
+// not requested again. Polling stops when the Config is closed.
+func Poll(interval time.Duration) Option {
+	return func(s *fileServer) {
+		s.poll = interval
+	}
+}
+
+// Invalidations makes StaticFS drop the cached content ETag of every file
+// name received on names, such as the events of an fsnotify watcher, so
+// rewrites that keep a file's size and mtime are still noticed. Names are
+// relative to the served root. Watching stops when names is closed or the
+// Config is closed.
+func Invalidations(names <-chan string) Option {
+	return func(s *fileServer) {
+		s.invalidations = names
+	}
+}
+
+// Starts the background invalidation the options ask for.
+func (s *fileServer) watch() {
+	if s.poll > 0 {
+		go s.pollHashes()
+	}
+	if s.invalidations != nil {
+		go s.receiveInvalidations()
+	}
+}
+
+func (s *fileServer) pollHashes() {
+	ticker := time.NewTicker(s.poll)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.cfg.Done():
+			return
+		case <-ticker.C:
+		}
+
+		s.mu.Lock()
+		names := make([]string, 0, len(s.hashes))
+		for name := range s.hashes {
+			names = append(names, name)
+		}
+		s.mu.Unlock()
+
+		for _, name := range names {
+			fi, err := fs.Stat(s.fsys, name)
+			s.mu.Lock()
+			if cached, ok := s.hashes[name]; ok && (err != nil || !cached.current(fi)) {
+				delete(s.hashes, name)
+			}
+			s.mu.Unlock()
+		}
+	}
+}
+
+func (s *fileServer) receiveInvalidations() {
+	for {
+		select {
+		case <-s.cfg.Done():
+			return
+		case name, ok := <-s.invalidations:
+			if !ok {
+				return
+			}
+			s.invalidate(strings.TrimPrefix(name, "/"))
+		}
+	}
+}
+
+func (s *fileServer) invalidate(name string) {
+	s.mu.Lock()
+	delete(s.hashes, name)
+	s.mu.Unlock()
+}
@@ -0,0 +1,110 @@
+package conditional
+
+import (
+	"context"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func staticEtag(t *testing.T, r *gin.Engine) string {
+	t.Helper()
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(Get, "/app.js", nil))
+	return w.Header().Get(ETag)
+}
+
+func TestContentEtagsFollowMetadata(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	modTime := time.Unix(1700000000, 0)
+	fsys := fstest.MapFS{"app.js": {Data: []byte("one"), ModTime: modTime}}
+	cfg := &Config{}
+	r := gin.New()
+	r.Use(cfg.StaticFS("/", fsys, ContentEtags()))
+
+	first := staticEtag(t, r)
+	fsys["app.js"] = &fstest.MapFile{Data: []byte("two!"), ModTime: modTime}
+	if staticEtag(t, r) == first {
+		t.Error("ETag kept after the size changed")
+	}
+}
+
+func TestInvalidations(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	modTime := time.Unix(1700000000, 0)
+	fsys := fstest.MapFS{"app.js": {Data: []byte("one"), ModTime: modTime}}
+	cfg := &Config{}
+	defer cfg.Close(context.Background())
+	names := make(chan string)
+	r := gin.New()
+	r.Use(cfg.StaticFS("/", fsys, ContentEtags(), Invalidations(names)))
+
+	first := staticEtag(t, r)
+	// Same size and mtime, only an invalidation reveals the change.
+	fsys["app.js"] = &fstest.MapFile{Data: []byte("two"), ModTime: modTime}
+	if staticEtag(t, r) != first {
+		t.Fatal("cached ETag not used")
+	}
+
+	names <- "/app.js"
+	for deadline := time.Now().Add(time.Second); staticEtag(t, r) == first; time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("ETag kept after the invalidation")
+		}
+	}
+}
+
+func TestRemovedFileDropsContentEtag(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	fsys := fstest.MapFS{"app.js": {Data: []byte("one"), ModTime: time.Unix(1700000000, 0)}}
+	cfg := &Config{}
+	r := gin.New()
+	r.Use(cfg.StaticFS("/", fsys, ContentEtags()))
+
+	staticEtag(t, r)
+	delete(fsys, "app.js")
+	staticEtag(t, r)
+	fsys["app.js"] = &fstest.MapFile{Data: []byte("two"), ModTime: time.Unix(1700000000, 0)}
+	if etag, _ := cfg.hashEtagReader(strings.NewReader("two")); staticEtag(t, r) != etag {
+		t.Error("ETag of the removed file still cached")
+	}
+}
+
+func TestPoll(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	root := t.TempDir()
+	for _, name := range []string{"app.js", "gone.js"} {
+		if err := os.WriteFile(filepath.Join(root, name), []byte("one"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cfg := &Config{}
+	defer cfg.Close(context.Background())
+	s := cfg.newFileServer(os.DirFS(root), []Option{ContentEtags(), Poll(time.Millisecond)})
+	r := gin.New()
+	r.Use(s.handle)
+
+	for _, name := range []string{"/app.js", "/gone.js"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(Get, name, nil))
+	}
+	os.WriteFile(filepath.Join(root, "app.js"), []byte("two!"), 0o644)
+	os.Remove(filepath.Join(root, "gone.js"))
+
+	for deadline := time.Now().Add(time.Second); ; time.Sleep(time.Millisecond) {
+		s.mu.Lock()
+		n := len(s.hashes)
+		s.mu.Unlock()
+		if n == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d stale hashes kept after polling", n)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package conditional
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// WriteBackStore is a ValidatorStore queueing Sets in memory and writing
+// them to the underlying store every interval, in one call when it is a
+// BatchValidatorStore, so requests do not wait on a remote store:
+//
+//	store := conditional.NewWriteBackStore(cfg, redisStore, time.Second)
+//
+// Reads see queued writes. The queue is flushed when cfg is closed, and
+// writes that fail are retried on the next flush and logged to ErrorLog.
+type WriteBackStore struct {
+	store ValidatorStore
+	cfg   *Config
+
+	mu      sync.Mutex
+	pending map[string]Validators
+	stopped chan struct{}
+
+	// Held while writing, so an invalidation is never overtaken by the
+	// write of the validators it replaces.
+	flushing sync.Mutex
+}
+
+func NewWriteBackStore(cfg *Config, store ValidatorStore, interval time.Duration) *WriteBackStore {
+	if cfg == nil {
+		cfg = DefaultConfig
+	}
+	s := &WriteBackStore{
+		store:   store,
+		cfg:     cfg,
+		pending: make(map[string]Validators),
+		stopped: make(chan struct{}),
+	}
+	go s.run(interval)
+	cfg.OnClose(s.close)
+	return s
+}
+
+func (s *WriteBackStore) run(interval time.Duration) {
+	defer close(s.stopped)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.cfg.Done():
+			return
+		case <-ticker.C:
+		}
+		if err := s.Flush(context.Background()); err != nil {
+			s.cfg.logf("conditional: writing back validators: %v", err)
+		}
+	}
+}
+
+// Waits for a flush in progress, then writes what is still queued.
+func (s *WriteBackStore) close(ctx context.Context) error {
+	select {
+	case <-s.stopped:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return s.Flush(ctx)
+}
+
+func (s *WriteBackStore) Get(ctx context.Context, key string) (Validators, bool, error) {
+	s.mu.Lock()
+	v, ok := s.pending[key]
+	s.mu.Unlock()
+	if ok {
+		return v, true, nil
+	}
+	return s.store.Get(ctx, key)
+}
+
+// Set queues v for the next flush.
+func (s *WriteBackStore) Set(ctx context.Context, key string, v Validators) error {
+	s.mu.Lock()
+	s.pending[key] = v
+	s.mu.Unlock()
+	return nil
+}
+
+// Invalidate drops any queued write of key and invalidates it in the
+// underlying store once a flush in progress is done.
+func (s *WriteBackStore) Invalidate(ctx context.Context, key string) error {
+	s.flushing.Lock()
+	defer s.flushing.Unlock()
+
+	s.mu.Lock()
+	delete(s.pending, key)
+	s.mu.Unlock()
+	return s.store.Invalidate(ctx, key)
+}
+
+// Flush writes the queued validators to the underlying store. Writes that
+// fail are queued again, unless a newer one replaced them meanwhile.
+func (s *WriteBackStore) Flush(ctx context.Context) error {
+	s.flushing.Lock()
+	defer s.flushing.Unlock()
+
+	s.mu.Lock()
+	batch := s.pending
+	s.pending = make(map[string]Validators)
+	s.mu.Unlock()
+	if len(batch) == 0 {
+		return nil
+	}
+
+	failed := batch
+	var err error
+	if b, ok := s.store.(BatchValidatorStore); ok {
+		if err = b.SetValidators(ctx, batch); err == nil {
+			failed = nil
+		}
+	} else {
+		failed = make(map[string]Validators)
+		for key, v := range batch {
+			if serr := s.store.Set(ctx, key, v); serr != nil {
+				failed[key], err = v, serr
+			}
+		}
+	}
+
+	if len(failed) > 0 {
+		s.mu.Lock()
+		for key, v := range failed {
+			if _, ok := s.pending[key]; !ok {
+				s.pending[key] = v
+			}
+		}
+		s.mu.Unlock()
+	}
+	return err
+}